@@ -0,0 +1,49 @@
+REM Procedures: nested calls, recursion, RETURN and END
+LET N = 3
+
+CALL Outer
+PRINT "Back in the main program"
+
+CALL Countdown
+PRINT "Countdown finished"
+
+REM Parameters are passed by value and hide globals of the same name
+LET W = 100
+ShowArea(3, 4)
+PRINT "W is still "; W
+PRINT "5! = "; Factorial(5)
+
+CALL Stop
+PRINT "This line is never reached"
+
+PROC Outer {
+  PRINT "Outer: start"
+  CALL Inner
+  PRINT "Outer: end"
+}
+
+PROC Inner {
+  PRINT "Inner: returning early"
+  RETURN
+  PRINT "Inner: this line is skipped"
+}
+
+PROC Countdown {
+  PRINT N
+  LET N = N - 1
+  IF N > 0 THEN CALL Countdown
+}
+
+PROC ShowArea(W, H) {
+  PRINT "Area: "; W * H
+}
+
+FUNC Factorial(N) {
+  IF N <= 1 THEN RETURN 1
+  RETURN N * Factorial(N - 1)
+}
+
+PROC Stop {
+  PRINT "Stopping the program from inside a procedure"
+  END
+}
@@ -0,0 +1,122 @@
+package interpreter
+
+import (
+	"fmt"
+	"pati/patistructs"
+	"strings"
+)
+
+// MaxArrayElements is the largest number of elements a single array may have, counting every dimension
+const MaxArrayElements = 1 << 20
+
+// array is the storage of a dimensioned array, with the elements of the last dimension stored next to each other
+type array struct {
+	base   int   // Lowest subscript of every dimension
+	bounds []int // Highest subscript of each dimension
+	values []patistructs.Value
+}
+
+// Execute a DIM statement, creating every array it declares with all elements set to 0 or ""
+func (i *Interpreter) executeDim(dimNode *patistructs.DimStatementNode) {
+	if dimNode == nil {
+		return
+	}
+
+	for _, declaration := range dimNode.Arrays {
+		name := i.symbols.Name(declaration.Array)
+		if i.arrays[declaration.Array] != nil {
+			i.runtimeError(50, fmt.Sprintf("array %s is already dimensioned", name))
+			return
+		}
+
+		dimensioned := &array{base: declaration.Base}
+		size := 1
+		for _, boundExpression := range declaration.Bounds {
+			value := i.evaluateExpression(boundExpression)
+			if i.errors.GetCode() != 0 {
+				return
+			}
+			bound, ok := i.integerValue("DIM "+name, value)
+			if !ok {
+				return
+			}
+			if bound < declaration.Base {
+				i.runtimeError(49, fmt.Sprintf("DIM %s: bound %d is below the lowest subscript %d", name, bound, declaration.Base))
+				return
+			}
+			// Checked before multiplying, so the size can neither overflow nor pass the limit
+			if bound-declaration.Base >= MaxArrayElements/size {
+				i.runtimeError(61, fmt.Sprintf("DIM %s: array too large, the limit is %d elements", name, MaxArrayElements))
+				return
+			}
+			dimensioned.bounds = append(dimensioned.bounds, bound)
+			size *= bound - declaration.Base + 1
+		}
+
+		initial := patistructs.IntValue(0)
+		if patistructs.VariableClass(name) == patistructs.VALUE_STRING {
+			initial = patistructs.StringValue("")
+		}
+		dimensioned.values = make([]patistructs.Value, size)
+		for n := range dimensioned.values {
+			dimensioned.values[n] = initial
+		}
+		i.arrays[declaration.Array] = dimensioned
+	}
+}
+
+// Helper function to evaluate the subscripts of an array element and find where it is stored
+func (i *Interpreter) arrayElement(slot int, indices []*patistructs.ExpressionNode) (*patistructs.Value, bool) {
+	name := i.symbols.Name(slot)
+	dimensioned := i.arrays[slot]
+	if dimensioned == nil {
+		i.runtimeError(48, fmt.Sprintf("array %s is used before DIM", name))
+		return nil, false
+	}
+	if len(indices) != len(dimensioned.bounds) {
+		i.runtimeError(46, fmt.Sprintf("array %s has %d subscripts, not %d", name, len(dimensioned.bounds), len(indices)))
+		return nil, false
+	}
+
+	// Subscripts are evaluated first so the message can show all of them
+	subscripts := make([]int, len(indices))
+	texts := make([]string, len(indices))
+	for n, index := range indices {
+		value := i.evaluateExpression(index)
+		if i.errors.GetCode() != 0 {
+			return nil, false
+		}
+		subscript, ok := i.integerValue("subscript of "+name, value) // Fractional subscripts are truncated
+		if !ok {
+			return nil, false
+		}
+		subscripts[n] = subscript
+		texts[n] = fmt.Sprint(subscripts[n])
+	}
+
+	offset := 0
+	for n, subscript := range subscripts {
+		if subscript < dimensioned.base || subscript > dimensioned.bounds[n] {
+			position := ""
+			if len(subscripts) > 1 {
+				position = fmt.Sprintf(" of subscript %d", n+1)
+			}
+			i.runtimeError(49, fmt.Sprintf("subscript out of range in %s(%s): the range%s is %d to %d",
+				name, strings.Join(texts, ", "), position, dimensioned.base, dimensioned.bounds[n]))
+			return nil, false
+		}
+		offset = offset*(dimensioned.bounds[n]-dimensioned.base+1) + subscript - dimensioned.base
+	}
+	return &dimensioned.values[offset], true
+}
+
+// Helper function to store a value in a plain variable or, when there are subscripts, in an array element
+func (i *Interpreter) assign(slot int, indices []*patistructs.ExpressionNode, value patistructs.Value) {
+	if indices == nil {
+		i.variables[slot] = value
+		return
+	}
+	if element, ok := i.arrayElement(slot, indices); ok {
+		*element = value
+	}
+}
@@ -0,0 +1,281 @@
+package interpreter
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"pati/patistructs"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// builtinImplementation computes a built-in function from its evaluated arguments, whose number and types the parser checked
+type builtinImplementation func(i *Interpreter, arguments []patistructs.Value) patistructs.Value
+
+// builtinImplementations maps each name in patistructs.Builtins to the code computing it
+var builtinImplementations = map[string]builtinImplementation{
+	"ABS": func(i *Interpreter, arguments []patistructs.Value) patistructs.Value {
+		if arguments[0].Class == patistructs.VALUE_INT {
+			if arguments[0].Int < 0 {
+				return patistructs.IntValue(-arguments[0].Int)
+			}
+			return arguments[0]
+		}
+		return patistructs.FloatValue(math.Abs(arguments[0].Float))
+	},
+	"SGN": func(i *Interpreter, arguments []patistructs.Value) patistructs.Value {
+		return patistructs.IntValue(compareValues(arguments[0], patistructs.IntValue(0)))
+	},
+	"INT": func(i *Interpreter, arguments []patistructs.Value) patistructs.Value {
+		// Rounds down, so INT(-2.5) is -3
+		n, _ := i.integerValue("INT", patistructs.FloatValue(math.Floor(arguments[0].AsFloat())))
+		return patistructs.IntValue(n)
+	},
+	"SQR": func(i *Interpreter, arguments []patistructs.Value) patistructs.Value {
+		if arguments[0].AsFloat() < 0 {
+			i.runtimeError(58, fmt.Sprintf("SQR(%s): the argument must not be negative", arguments[0]))
+			return patistructs.FloatValue(0)
+		}
+		return patistructs.FloatValue(math.Sqrt(arguments[0].AsFloat()))
+	},
+	"SIN": floatFunction(math.Sin),
+	"COS": floatFunction(math.Cos),
+	"ATN": floatFunction(math.Atan),
+	"EXP": floatFunction(math.Exp),
+	"LOG": func(i *Interpreter, arguments []patistructs.Value) patistructs.Value {
+		if arguments[0].AsFloat() <= 0 {
+			i.runtimeError(58, fmt.Sprintf("LOG(%s): the argument must be positive", arguments[0]))
+			return patistructs.FloatValue(0)
+		}
+		return patistructs.FloatValue(math.Log(arguments[0].AsFloat()))
+	},
+	"RND": func(i *Interpreter, arguments []patistructs.Value) patistructs.Value {
+		// Like classic BASIC: RND(0) repeats the last number and a negative argument restarts the sequence from it
+		if len(arguments) > 0 {
+			switch compareValues(arguments[0], patistructs.IntValue(0)) {
+			case 0:
+				return patistructs.FloatValue(i.lastRandom)
+			case -1:
+				i.random = rand.New(rand.NewSource(randomSeed(arguments[0])))
+			}
+		}
+		i.lastRandom = i.random.Float64()
+		return patistructs.FloatValue(i.lastRandom)
+	},
+	"MIN": extremeFunction(-1),
+	"MAX": extremeFunction(1),
+
+	"LEN": func(i *Interpreter, arguments []patistructs.Value) patistructs.Value {
+		return patistructs.IntValue(utf8.RuneCountInString(arguments[0].Text))
+	},
+	"LEFT$": func(i *Interpreter, arguments []patistructs.Value) patistructs.Value {
+		characters := []rune(arguments[0].Text)
+		count, ok := i.characterCount("LEFT$", arguments[1], len(characters))
+		if !ok {
+			return patistructs.StringValue("")
+		}
+		return patistructs.StringValue(string(characters[:count]))
+	},
+	"RIGHT$": func(i *Interpreter, arguments []patistructs.Value) patistructs.Value {
+		characters := []rune(arguments[0].Text)
+		count, ok := i.characterCount("RIGHT$", arguments[1], len(characters))
+		if !ok {
+			return patistructs.StringValue("")
+		}
+		return patistructs.StringValue(string(characters[len(characters)-count:]))
+	},
+	"MID$": func(i *Interpreter, arguments []patistructs.Value) patistructs.Value {
+		characters := []rune(arguments[0].Text)
+		start, ok := i.characterPosition("MID$", arguments[1], len(characters))
+		if !ok {
+			return patistructs.StringValue("")
+		}
+		rest := characters[start-1:]
+		if len(arguments) > 2 {
+			count, ok := i.characterCount("MID$", arguments[2], len(rest))
+			if !ok {
+				return patistructs.StringValue("")
+			}
+			rest = rest[:count]
+		}
+		return patistructs.StringValue(string(rest))
+	},
+	"INSTR": func(i *Interpreter, arguments []patistructs.Value) patistructs.Value {
+		if len(arguments) == 2 {
+			arguments = append([]patistructs.Value{patistructs.IntValue(1)}, arguments...)
+		}
+		characters := []rune(arguments[1].Text)
+		start, ok := i.characterPosition("INSTR", arguments[0], len(characters))
+		if !ok {
+			return patistructs.IntValue(0)
+		}
+		index := strings.Index(string(characters[start-1:]), arguments[2].Text)
+		if index < 0 {
+			return patistructs.IntValue(0)
+		}
+		return patistructs.IntValue(start + utf8.RuneCountInString(string(characters[start-1:])[:index]))
+	},
+	"UCASE$": stringFunction(strings.ToUpper),
+	"LCASE$": stringFunction(strings.ToLower),
+	"TRIM$":  stringFunction(strings.TrimSpace),
+	"STR$": func(i *Interpreter, arguments []patistructs.Value) patistructs.Value {
+		return patistructs.StringValue(arguments[0].String()) // The same text PRINT shows
+	},
+	"VAL": func(i *Interpreter, arguments []patistructs.Value) patistructs.Value {
+		return numericPrefix(arguments[0].Text)
+	},
+	"CHR$": func(i *Interpreter, arguments []patistructs.Value) patistructs.Value {
+		code, ok := i.integerValue("CHR$", arguments[0])
+		if !ok {
+			return patistructs.StringValue("")
+		}
+		if code < 0 || code > utf8.MaxRune || !utf8.ValidRune(rune(code)) {
+			i.runtimeError(58, fmt.Sprintf("CHR$(%s): %s is not a character code", arguments[0], arguments[0]))
+			return patistructs.StringValue("")
+		}
+		return patistructs.StringValue(string(rune(code)))
+	},
+	"ASC": func(i *Interpreter, arguments []patistructs.Value) patistructs.Value {
+		if arguments[0].Text == "" {
+			i.runtimeError(58, "ASC(\"\"): the string is empty")
+			return patistructs.IntValue(0)
+		}
+		character, _ := utf8.DecodeRuneInString(arguments[0].Text)
+		return patistructs.IntValue(int(character))
+	},
+}
+
+// Helper function to wrap a float function of one argument as a built-in
+func floatFunction(f func(float64) float64) builtinImplementation {
+	return func(i *Interpreter, arguments []patistructs.Value) patistructs.Value {
+		return patistructs.FloatValue(f(arguments[0].AsFloat()))
+	}
+}
+
+// Helper function to wrap a function from string to string as a built-in
+func stringFunction(f func(string) string) builtinImplementation {
+	return func(i *Interpreter, arguments []patistructs.Value) patistructs.Value {
+		return patistructs.StringValue(f(arguments[0].Text))
+	}
+}
+
+// Helper function to check the number of characters taken by LEFT$, RIGHT$ or MID$, limited to the length available
+func (i *Interpreter) characterCount(name string, count patistructs.Value, available int) (int, bool) {
+	n, ok := i.integerValue(name, count)
+	if !ok {
+		return 0, false
+	}
+	if n < 0 {
+		i.runtimeError(58, fmt.Sprintf("%s: the number of characters %s must not be negative", name, count))
+		return 0, false
+	}
+	return min(n, available), true
+}
+
+// Helper function to check the position, counting from 1, where MID$ or INSTR starts; one past the end is allowed
+func (i *Interpreter) characterPosition(name string, position patistructs.Value, length int) (int, bool) {
+	n, ok := i.integerValue(name, position)
+	if !ok {
+		return 0, false
+	}
+	if n < 1 {
+		i.runtimeError(58, fmt.Sprintf("%s: the position %s must be at least 1", name, position))
+		return 0, false
+	}
+	return min(n, length+1), true
+}
+
+// Helper function to read the number at the start of a string for VAL, ignoring leading spaces; 0 if there is none
+func numericPrefix(text string) patistructs.Value {
+	text = strings.TrimLeft(text, " \t")
+	end := 0
+	if end < len(text) && (text[end] == '+' || text[end] == '-') {
+		end++
+	}
+	digits := func() {
+		for end < len(text) && text[end] >= '0' && text[end] <= '9' {
+			end++
+		}
+	}
+	digits()
+	float := false
+	if end < len(text) && text[end] == '.' {
+		end++
+		digits()
+		float = true
+	}
+	if end < len(text) && (text[end] == 'E' || text[end] == 'e') {
+		exponent := end
+		end++
+		if end < len(text) && (text[end] == '+' || text[end] == '-') {
+			end++
+		}
+		mark := end
+		digits()
+		if end == mark {
+			end = exponent // "2E" is just 2
+		} else {
+			float = true
+		}
+	}
+
+	if !float {
+		if n, err := strconv.Atoi(text[:end]); err == nil {
+			return patistructs.IntValue(n)
+		}
+	}
+	if f, err := strconv.ParseFloat(text[:end], 64); err == nil {
+		return patistructs.FloatValue(f)
+	}
+	return patistructs.IntValue(0)
+}
+
+// Helper function to build MIN (direction -1) or MAX (direction 1), which return a float if any argument is one
+func extremeFunction(direction int) builtinImplementation {
+	return func(i *Interpreter, arguments []patistructs.Value) patistructs.Value {
+		result := arguments[0]
+		float := false
+		for _, argument := range arguments {
+			if compareValues(argument, result) == direction {
+				result = argument
+			}
+			float = float || argument.Class == patistructs.VALUE_FLOAT
+		}
+		if float {
+			return patistructs.FloatValue(result.AsFloat())
+		}
+		return result
+	}
+}
+
+// Call a built-in function with arguments passed by value
+func (i *Interpreter) callBuiltin(implementation builtinImplementation, arguments []*patistructs.ArgumentNode) patistructs.Value {
+	values := make([]patistructs.Value, len(arguments))
+	for n, argument := range arguments {
+		values[n] = i.evaluateExpression(argument.Expression)
+		if i.errors.GetCode() != 0 {
+			return patistructs.IntValue(0)
+		}
+	}
+	return implementation(i, values)
+}
+
+// Execute a RANDOMIZE statement, restarting the sequence of RND from a seed
+func (i *Interpreter) executeRandomize(seed *patistructs.ExpressionNode) {
+	if seed == nil {
+		i.random = rand.New(rand.NewSource(time.Now().UnixNano()))
+		return
+	}
+	value := i.evaluateExpression(seed)
+	if i.errors.GetCode() != 0 {
+		return
+	}
+	i.random = rand.New(rand.NewSource(randomSeed(value)))
+}
+
+// Helper function to turn a numeric value into a seed, so that 1 and 1.0 give the same sequence
+func randomSeed(value patistructs.Value) int64 {
+	return int64(math.Float64bits(value.AsFloat()))
+}
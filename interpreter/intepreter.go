@@ -2,39 +2,115 @@
 package interpreter
 
 import (
+	"bufio"
 	"fmt"
+	"io"
+	"math/rand"
+	"os"
 	"pati/patistructs"
 	"strconv" // Added for converting int to string
+	"strings"
+	"unicode/utf8"
 )
 
 // Interpreter struct to maintain the state of the interpreter
 type Interpreter struct {
-	variables    map[string]interface{}             // Use a map for variables to support different types
-	errors       patistructs.ErrorHandler           // Error handler
-	currentLine  *patistructs.ProgramLineNode       // Current line for RETURN
-	lineStack    []*patistructs.ProgramLineNode     // Stack for GOSUB and RETURN
-	procedures   map[string]*patistructs.ProgramLineNode // Map of procedure names to nodes
+	variables    []patistructs.Value                   // Variable values indexed by symbol table slot, VALUE_NONE until assigned
+	arrays       []*array                              // Arrays indexed by symbol table slot, nil until dimensioned
+	symbols      *patistructs.SymbolTable              // Names of the variable slots, for diagnostics
+	errors       *patistructs.Diagnostics              // Every runtime error, with its line and message
+	currentLine  *patistructs.ProgramLineNode          // Current line for RETURN
+	lineStack    []callFrame                           // Stack for GOSUB and RETURN, and for procedure calls
+	options      *patistructs.LanguageOptions          // GosubLimit bounds the depth of lineStack
+	procedures   map[string]*patistructs.ProcedureNode // Map of procedure names to nodes
+	outputColumn int                                   // Cursor column used for PRINT zones
+	input        *bufio.Reader                         // Line reader used by INPUT
+	output       io.Writer                             // Destination of PRINT and of the INPUT prompts
+	flow         flowState                             // How control leaves the current line chain
+	returnValue  patistructs.Value                     // Value of the last RETURN inside a FUNC
+	jumpTarget   *patistructs.ProgramLineNode          // Line a GOTO is heading for while flow is flowGoto
+	random       *rand.Rand                            // Source of RND, restarted by RANDOMIZE
+	lastRandom   float64                               // Number last returned by RND, repeated by RND(0)
 }
 
+// flowState describes how control continues after a statement
+type flowState int
+
+const (
+	flowNext    flowState = iota // Continue with the next line
+	flowReturn                   // Return to the statement after the CALL
+	flowEnd                      // Stop the whole program
+	flowExitFor                  // Leave the innermost FOR loop
+	flowExitDo                   // Leave the innermost DO loop
+	flowGoto                     // Leave line chains until the one holding jumpTarget
+)
+
+// callFrame records a CALL, function call or GOSUB in progress
+type callFrame struct {
+	caller *patistructs.ProgramLineNode // Line to resume after RETURN
+	callee string                       // Procedure or subroutine called, for the stack overflow message
+}
+
+// Width of a PRINT zone, used when items are separated by ','
+const printZoneWidth = 14
+
 // NewInterpreter creates a new instance of the interpreter
-func NewInterpreter(errors patistructs.ErrorHandler) *Interpreter {
+func NewInterpreter(errors *patistructs.Diagnostics, options *patistructs.LanguageOptions) *Interpreter {
 	return &Interpreter{
-		variables:    make(map[string]interface{}),
-		errors:       errors,
-		lineStack:    []*patistructs.ProgramLineNode{},
-		procedures:   make(map[string]*patistructs.ProgramLineNode),
+		symbols:    patistructs.NewSymbolTable(),
+		errors:     errors,
+		lineStack:  []callFrame{},
+		options:    options,
+		random:     rand.New(rand.NewSource(1)), // The same numbers on every run until RANDOMIZE
+		procedures: make(map[string]*patistructs.ProcedureNode),
+		input:      bufio.NewReader(os.Stdin),
+		output:     os.Stdout,
 	}
 }
 
+// RunProgram executes the main program until it falls off the end, reaches END or fails
 func (i *Interpreter) RunProgram(program *patistructs.ProgramNode) {
-	// Initialize procedures
+	// Initialize procedures and one storage slot per variable
 	i.procedures = program.Procedures
-	i.currentLine = program.Main
+	if program.Symbols != nil {
+		i.symbols = program.Symbols
+	}
+	i.variables = make([]patistructs.Value, len(i.symbols.Names))
+	i.arrays = make([]*array, len(i.symbols.Names))
+	i.flow = flowNext
 
 	// Execute the main program
-	for i.currentLine != nil {
-		i.executeStatement(i.currentLine.Statement)
-		i.currentLine = i.currentLine.Next
+	i.executeLines(program.Main)
+}
+
+// Execute a chain of program lines until it ends or control flow leaves it
+func (i *Interpreter) executeLines(first *patistructs.ProgramLineNode) {
+	for line := first; line != nil; line = line.Next {
+		i.currentLine = line
+		i.executeStatement(line.Statement)
+		for i.flow == flowGoto && i.jumpTarget.Block == line.Block {
+			// The target is in this chain, so carry on from there
+			i.flow = flowNext
+			line = i.jumpTarget
+			i.currentLine = line
+			i.executeStatement(line.Statement)
+		}
+		if i.flow != flowNext || i.errors.GetCode() != 0 {
+			return
+		}
+	}
+}
+
+// DumpVariables writes every assigned variable with its source name, for debugging
+func (i *Interpreter) DumpVariables(w io.Writer) {
+	for slot, value := range i.variables {
+		switch value.Class {
+		case patistructs.VALUE_NONE:
+		case patistructs.VALUE_STRING:
+			fmt.Fprintf(w, "%s = %q\n", i.symbols.Name(slot), value.Text)
+		default:
+			fmt.Fprintf(w, "%s = %s\n", i.symbols.Name(slot), value)
+		}
 	}
 }
 
@@ -53,95 +129,257 @@ func (i *Interpreter) executeStatement(statement *patistructs.StatementNode) {
 		i.executePrint(statement.PrintNode)
 	case patistructs.STATEMENT_INPUT:
 		i.executeInput(statement.InputNode)
+	case patistructs.STATEMENT_WHILE:
+		i.executeWhile(statement.WhileNode)
+	case patistructs.STATEMENT_DO:
+		i.executeDo(statement.DoNode)
+	case patistructs.STATEMENT_FOR:
+		i.executeFor(statement.ForNode)
+	case patistructs.STATEMENT_EXIT:
+		i.executeExit(statement.ExitClass)
+	case patistructs.STATEMENT_DIM:
+		i.executeDim(statement.DimNode)
+	case patistructs.STATEMENT_RANDOMIZE:
+		i.executeRandomize(statement.Seed)
+	case patistructs.STATEMENT_OPTION:
+		// OPTION BASE only changes how the DIM statements after it were parsed
+	case patistructs.STATEMENT_GOTO:
+		i.executeGoto(statement.JumpNode)
+	case patistructs.STATEMENT_GOSUB:
+		i.executeGosub(statement.JumpNode)
 	case patistructs.STATEMENT_CALL:
-		i.executeCall(statement.CallName, statement.Arguments)
+		i.executeCall(statement.CallName, statement.CallSource, statement.Arguments)
 	case patistructs.STATEMENT_RETURN:
-		i.executeReturn()
+		i.executeReturn(statement.ReturnValue)
 	case patistructs.STATEMENT_END:
 		i.executeEnd()
 	default:
-		i.errors.SetCode(7, 0) // Unrecognized statement error
+		i.runtimeError(7, "") // Error: Statement cannot be executed
 	}
 }
 
-// Expanded evaluateExpression: Handles operators and nested expressions
-func (i *Interpreter) evaluateExpression(expr *patistructs.ExpressionNode) int {
+// Evaluate an expression: conjunctions joined by OR, stopping at the first true one
+func (i *Interpreter) evaluateExpression(expr *patistructs.ExpressionNode) patistructs.Value {
+	if expr == nil || expr.First == nil {
+		return patistructs.IntValue(0)
+	}
+	if expr.First.Next == nil {
+		return i.evaluateConjunction(expr.First)
+	}
+
+	for conjunction := expr.First; conjunction != nil; conjunction = conjunction.Next {
+		if i.evaluateConjunction(conjunction).IsTrue() {
+			return patistructs.BoolValue(true)
+		}
+		if i.errors.GetCode() != 0 {
+			break
+		}
+	}
+	return patistructs.BoolValue(false)
+}
+
+// Evaluate a conjunction: relations joined by AND, stopping at the first false one
+func (i *Interpreter) evaluateConjunction(conjunction *patistructs.ConjunctionNode) patistructs.Value {
+	if conjunction.First.Next == nil {
+		return i.evaluateRelation(conjunction.First)
+	}
+
+	for relation := conjunction.First; relation != nil; relation = relation.Next {
+		if !i.evaluateRelation(relation).IsTrue() || i.errors.GetCode() != 0 {
+			return patistructs.BoolValue(false)
+		}
+	}
+	return patistructs.BoolValue(true)
+}
+
+// Evaluate a relation: a sum, compared with a second sum and negated by NOT when present
+func (i *Interpreter) evaluateRelation(relation *patistructs.RelationNode) patistructs.Value {
+	value := i.evaluateSum(relation.Left)
+	if relation.Right != nil && i.errors.GetCode() == 0 {
+		rightValue := i.evaluateSum(relation.Right)
+		if i.errors.GetCode() != 0 {
+			return patistructs.IntValue(0)
+		}
+		value = patistructs.BoolValue(i.compareWith(value, relation.Op, rightValue))
+	}
+	if relation.Not {
+		value = patistructs.BoolValue(!value.IsTrue())
+	}
+	return value
+}
+
+// Evaluate a sum: Handles '+' and '-' between terms
+func (i *Interpreter) evaluateSum(expr *patistructs.SumNode) patistructs.Value {
 	if expr == nil {
-		return 0
+		return patistructs.IntValue(0)
 	}
 
 	termValue := i.evaluateTerm(expr.Term)
 	currentTerm := expr.Next
 	for currentTerm != nil {
+		// Stop at the first error, so one missing variable is reported once
+		if i.errors.GetCode() != 0 {
+			return patistructs.IntValue(0)
+		}
 		rightValue := i.evaluateTerm(currentTerm.Term)
+		if i.errors.GetCode() != 0 {
+			return patistructs.IntValue(0)
+		}
+		if (termValue.Class == patistructs.VALUE_STRING) != (rightValue.Class == patistructs.VALUE_STRING) {
+			i.runtimeError(14, "") // Error: Value of the wrong type
+			return patistructs.IntValue(0)
+		}
 		switch currentTerm.Op {
 		case patistructs.EXPRESSION_OPERATOR_PLUS:
-			termValue += rightValue
+			if termValue.Class == patistructs.VALUE_STRING {
+				termValue.Text += rightValue.Text // String concatenation
+			} else {
+				termValue = numericOperation(termValue, rightValue,
+					func(a, b int) int { return a + b },
+					func(a, b float64) float64 { return a + b })
+			}
 		case patistructs.EXPRESSION_OPERATOR_MINUS:
-			termValue -= rightValue
+			termValue = numericOperation(termValue, rightValue,
+				func(a, b int) int { return a - b },
+				func(a, b float64) float64 { return a - b })
 		default:
-			i.errors.SetCode(9, 0) // Error: Unknown expression operator
+			i.runtimeError(9, "") // Error: Unknown expression operator
 		}
 		currentTerm = currentTerm.Next
 	}
 	return termValue
 }
 
-// Evaluate a term: Handles multiplication and division
-func (i *Interpreter) evaluateTerm(term *patistructs.TermNode) int {
+// Evaluate a term: Handles multiplication, division, integer division and MOD
+func (i *Interpreter) evaluateTerm(term *patistructs.TermNode) patistructs.Value {
 	if term == nil {
-		return 0
+		return patistructs.IntValue(0)
 	}
 
 	factorValue := i.evaluateFactor(term.Factor)
 	currentFactor := term.Next
 	for currentFactor != nil {
+		if i.errors.GetCode() != 0 {
+			return patistructs.IntValue(0)
+		}
 		rightValue := i.evaluateFactor(currentFactor.Factor)
+		if i.errors.GetCode() != 0 {
+			return patistructs.IntValue(0)
+		}
+		if !factorValue.IsNumeric() || !rightValue.IsNumeric() {
+			i.runtimeError(14, "") // Error: Value of the wrong type
+			return patistructs.IntValue(0)
+		}
 		switch currentFactor.Op {
 		case patistructs.TERM_OPERATOR_MULTIPLY:
-			factorValue *= rightValue
+			factorValue = numericOperation(factorValue, rightValue,
+				func(a, b int) int { return a * b },
+				func(a, b float64) float64 { return a * b })
 		case patistructs.TERM_OPERATOR_DIVIDE:
-			if rightValue == 0 {
-				i.errors.SetCode(10, 0) // Error: Division by zero
-				return 0
+			// '/' always divides as floats, so 7 / 2 is 3.5
+			if rightValue.AsFloat() == 0 {
+				i.runtimeError(10, "") // Error: Division by zero
+				return patistructs.IntValue(0)
+			}
+			factorValue = patistructs.FloatValue(factorValue.AsFloat() / rightValue.AsFloat())
+		case patistructs.TERM_OPERATOR_INTEGER_DIVIDE, patistructs.TERM_OPERATOR_MOD:
+			// '\' and MOD truncate both operands to integers first
+			operator := "\\"
+			if currentFactor.Op == patistructs.TERM_OPERATOR_MOD {
+				operator = "MOD"
+			}
+			dividend, ok := i.integerValue(operator, factorValue)
+			if !ok {
+				return patistructs.IntValue(0)
+			}
+			divisor, ok := i.integerValue(operator, rightValue)
+			if !ok {
+				return patistructs.IntValue(0)
+			}
+			if divisor == 0 {
+				i.runtimeError(10, "") // Error: Division by zero
+				return patistructs.IntValue(0)
+			}
+			if currentFactor.Op == patistructs.TERM_OPERATOR_MOD {
+				factorValue = patistructs.IntValue(dividend % divisor)
+			} else {
+				factorValue = patistructs.IntValue(dividend / divisor)
 			}
-			factorValue /= rightValue
 		default:
-			i.errors.SetCode(11, 0) // Error: Unknown term operator
+			i.runtimeError(11, "") // Error: Unknown term operator
 		}
 		currentFactor = currentFactor.Next
 	}
 	return factorValue
 }
 
-// Evaluate a factor: Handles variables and values
-func (i *Interpreter) evaluateFactor(factor *patistructs.FactorNode) int {
+// Helper function to apply an arithmetic operator, promoting both sides to float if either is a float
+func numericOperation(left, right patistructs.Value, intOp func(a, b int) int, floatOp func(a, b float64) float64) patistructs.Value {
+	if left.Class == patistructs.VALUE_FLOAT || right.Class == patistructs.VALUE_FLOAT {
+		return patistructs.FloatValue(floatOp(left.AsFloat(), right.AsFloat()))
+	}
+	return patistructs.IntValue(intOp(left.Int, right.Int))
+}
+
+// Evaluate a factor: Handles variables, values and unary minus
+func (i *Interpreter) evaluateFactor(factor *patistructs.FactorNode) patistructs.Value {
 	if factor == nil {
-		return 0
+		return patistructs.IntValue(0)
 	}
 
+	var result patistructs.Value
 	switch factor.Class {
 	case patistructs.FACTOR_VALUE:
-		return factor.Value
+		result = factor.Value
 	case patistructs.FACTOR_VARIABLE:
-		key := strconv.Itoa(factor.Variable) // Convert int to string for map key
-		value, ok := i.variables[key]
-		if !ok {
-			i.errors.SetCode(13, 0) // Error: Variable not found
-			return 0
+		result = i.variables[factor.Variable]
+		if result.Class == patistructs.VALUE_NONE {
+			i.runtimeError(13, fmt.Sprintf("variable %s not found", i.symbols.Name(factor.Variable))) // Error: Variable not found
+			return patistructs.IntValue(0)
 		}
-		intValue, ok := value.(int) // Assert interface{} to int
+	case patistructs.FACTOR_EXPRESSION:
+		result = i.evaluateExpression(factor.Expression)
+	case patistructs.FACTOR_ARRAY:
+		element, ok := i.arrayElement(factor.Variable, factor.Indices)
 		if !ok {
-			i.errors.SetCode(14, 0) // Error: Variable type mismatch
-			return 0
+			return patistructs.IntValue(0)
 		}
-		return intValue
-	case patistructs.FACTOR_EXPRESSION:
-		return i.evaluateExpression(factor.Expression)
+		result = *element
+	case patistructs.FACTOR_CALL:
+		result = i.callProcedure(factor.CallName, factor.CallSource, factor.Arguments)
 	default:
-		i.errors.SetCode(12, 0) // Error: Unknown factor class
+		i.runtimeError(12, "") // Error: Unknown factor class
+		return patistructs.IntValue(0)
+	}
+
+	if factor.Sign < 0 {
+		result.Int = -result.Int
+		result.Float = -result.Float
+	}
+	return result
+}
+
+// Helper function to compare two strings or two numbers, returning -1, 0 or 1
+func compareValues(left, right patistructs.Value) int {
+	if left.Class == patistructs.VALUE_STRING {
+		return strings.Compare(left.Text, right.Text)
+	}
+	if left.Class == patistructs.VALUE_INT && right.Class == patistructs.VALUE_INT {
+		switch {
+		case left.Int < right.Int:
+			return -1
+		case left.Int > right.Int:
+			return 1
+		}
 		return 0
 	}
+	switch {
+	case left.AsFloat() < right.AsFloat():
+		return -1
+	case left.AsFloat() > right.AsFloat():
+		return 1
+	}
+	return 0
 }
 
 // Execute a LET statement
@@ -151,8 +389,10 @@ func (i *Interpreter) executeLet(letNode *patistructs.LetStatementNode) {
 	}
 
 	value := i.evaluateExpression(letNode.Expression)
-	key := strconv.Itoa(letNode.Variable) // Convert int to string for map key
-	i.variables[key] = value
+	if i.errors.GetCode() != 0 {
+		return
+	}
+	i.assign(letNode.Variable, letNode.Indices, value)
 }
 
 // Execute an IF statement
@@ -161,37 +401,100 @@ func (i *Interpreter) executeIf(ifNode *patistructs.IfStatementNode) {
 		return
 	}
 
-	leftValue := i.evaluateExpression(ifNode.Left)
-	rightValue := i.evaluateExpression(ifNode.Right)
+	conditionMet := i.evaluateExpression(ifNode.Condition).IsTrue()
+	if i.errors.GetCode() != 0 {
+		return
+	}
+
+	switch {
+	case !ifNode.Block && conditionMet:
+		i.executeStatements(ifNode.Statement)
+	case !ifNode.Block:
+		i.executeStatements(ifNode.Else)
+	case conditionMet:
+		i.executeLines(ifNode.Then)
+	case ifNode.ElseIf != nil:
+		i.executeIf(ifNode.ElseIf)
+	default:
+		i.executeLines(ifNode.ElseLines)
+	}
+}
+
+// Execute the statements of a single-line IF branch, which ':' chains together
+func (i *Interpreter) executeStatements(first *patistructs.StatementNode) {
+	for statement := first; statement != nil; statement = statement.Next {
+		i.executeStatement(statement)
+		if i.flow != flowNext || i.errors.GetCode() != 0 {
+			return
+		}
+	}
+}
 
-	conditionMet := false
-	switch ifNode.Op {
+// Helper function to apply a relational operator to two values
+func (i *Interpreter) compareWith(leftValue patistructs.Value, op patistructs.RelationalOperator, rightValue patistructs.Value) bool {
+	if (leftValue.Class == patistructs.VALUE_STRING) != (rightValue.Class == patistructs.VALUE_STRING) {
+		i.runtimeError(14, "") // Error: Value of the wrong type
+		return false
+	}
+	comparison := compareValues(leftValue, rightValue)
+
+	switch op {
 	case patistructs.RELOP_EQUAL:
-		conditionMet = (leftValue == rightValue)
+		return comparison == 0
 	case patistructs.RELOP_UNEQUAL:
-		conditionMet = (leftValue != rightValue)
+		return comparison != 0
 	case patistructs.RELOP_LESSTHAN:
-		conditionMet = (leftValue < rightValue)
+		return comparison < 0
 	case patistructs.RELOP_LESSOREQUAL:
-		conditionMet = (leftValue <= rightValue)
+		return comparison <= 0
 	case patistructs.RELOP_GREATERTHAN:
-		conditionMet = (leftValue > rightValue)
+		return comparison > 0
 	case patistructs.RELOP_GREATEROREQUAL:
-		conditionMet = (leftValue >= rightValue)
-	}
-
-	if conditionMet {
-		i.executeStatement(ifNode.Statement)
+		return comparison >= 0
 	}
+	return false
 }
 
 // Execute a PRINT statement
 func (i *Interpreter) executePrint(printNode *patistructs.PrintStatementNode) {
-	if printNode == nil || printNode.First == nil {
+	if printNode == nil {
 		return
 	}
 
-	fmt.Println(printNode.First.Value) // Simplified to print a single value for now
+	newline := true
+	for output := printNode.First; output != nil; output = output.Next {
+		switch output.Class {
+		case patistructs.OUTPUT_STRING:
+			i.writeOutput(output.Value)
+		case patistructs.OUTPUT_EXPRESSION:
+			value := i.evaluateExpression(output.Expression)
+			if i.errors.GetCode() != 0 {
+				return
+			}
+			i.writeOutput(value.String())
+		}
+
+		if output.Separator == patistructs.OUTPUT_SEPARATOR_COMMA {
+			// Pad to the start of the next print zone
+			i.writeOutput(strings.Repeat(" ", printZoneWidth-i.outputColumn%printZoneWidth))
+		}
+		// A trailing separator keeps the cursor on the current line
+		newline = output.Separator == patistructs.OUTPUT_SEPARATOR_NONE
+	}
+
+	if newline {
+		i.writeOutput("\n")
+	}
+}
+
+// Helper function to write text to the output while tracking the cursor column
+func (i *Interpreter) writeOutput(text string) {
+	fmt.Fprint(i.output, text)
+	if index := strings.LastIndex(text, "\n"); index >= 0 {
+		i.outputColumn = utf8.RuneCountInString(text[index+1:])
+	} else {
+		i.outputColumn += utf8.RuneCountInString(text)
+	}
 }
 
 // Execute an INPUT statement
@@ -200,48 +503,236 @@ func (i *Interpreter) executeInput(inputNode *patistructs.InputStatementNode) {
 		return
 	}
 
-	for _, variableIndex := range inputNode.First.Variables {
-		var input int
-		fmt.Printf("Enter value for variable %c: ", 'A'+variableIndex)
-		_, err := fmt.Scan(&input)
-		if err != nil {
-			i.errors.SetCode(8, 0) // Error handling input
+	prompt := inputNode.Prompt
+	if inputNode.QuestionMark {
+		prompt += "? "
+	}
+
+	variables := inputNode.First.Variables
+	values := make([]patistructs.Value, 0, len(variables))
+	i.writeOutput(prompt)
+	for len(values) < len(variables) {
+		line, err := i.input.ReadString('\n')
+		if err != nil && line == "" {
+			i.runtimeError(8, "") // Error handling input
+			return
+		}
+		i.outputColumn = 0 // The user's newline moved the cursor
+
+		answers := strings.Split(strings.TrimRight(line, "\r\n"), ",")
+		extra := false
+		if missing := len(variables) - len(values); len(answers) > missing {
+			// Answers beyond the variable list are dropped without being checked
+			answers = answers[:missing]
+			extra = true
+		}
+		parsed := make([]patistructs.Value, 0, len(answers))
+		for index, answer := range answers {
+			class := patistructs.VariableClass(i.symbols.Name(variables[len(values)+index]))
+			value, ok := parseInputValue(strings.TrimSpace(answer), class)
+			if !ok {
+				parsed = nil
+				break
+			}
+			parsed = append(parsed, value)
+		}
+		if parsed == nil {
+			// Discard everything entered so far and ask again
+			values = values[:0]
+			i.writeOutput("?Redo from start\n")
+			i.writeOutput(prompt)
+			continue
+		}
+
+		if extra {
+			i.writeOutput("?Extra ignored\n")
+		}
+		values = append(values, parsed...)
+		if len(values) < len(variables) {
+			i.writeOutput("?? ")
+		}
+	}
+
+	for index, variableIndex := range variables {
+		var indices []*patistructs.ExpressionNode
+		if index < len(inputNode.First.Indices) {
+			indices = inputNode.First.Indices[index]
+		}
+		i.assign(variableIndex, indices, values[index])
+		if i.errors.GetCode() != 0 {
 			return
 		}
-		key := strconv.Itoa(variableIndex) // Convert int to string for map key
-		i.variables[key] = input
 	}
 }
 
-// Execute a CALL statement
-func (i *Interpreter) executeCall(name string, arguments []*patistructs.ArgumentNode) {
+// Helper function to convert an INPUT answer to a value of the variable's class
+func parseInputValue(answer string, class patistructs.ValueClass) (patistructs.Value, bool) {
+	if class == patistructs.VALUE_STRING {
+		return patistructs.StringValue(answer), true
+	}
+	if n, err := strconv.Atoi(answer); err == nil {
+		return patistructs.IntValue(n), true
+	}
+	f, err := strconv.ParseFloat(answer, 64)
+	if err != nil {
+		return patistructs.Value{}, false
+	}
+	return patistructs.FloatValue(f), true
+}
+
+// Execute a CALL statement: run the whole procedure body, then resume after the call site
+func (i *Interpreter) executeCall(name string, source string, arguments []*patistructs.ArgumentNode) {
+	i.callProcedure(name, source, arguments) // The value of a FUNC called as a statement is discarded
+}
+
+// Call a procedure or built-in function with arguments passed by value and return the value of its RETURN, if it is a FUNC; source is the name as written, for messages
+func (i *Interpreter) callProcedure(name string, source string, arguments []*patistructs.ArgumentNode) patistructs.Value {
+	if builtin, exists := builtinImplementations[name]; exists {
+		return i.callBuiltin(builtin, arguments)
+	}
 	procedure, exists := i.procedures[name]
 	if !exists {
-		i.errors.SetCode(20, 0) // Error: Procedure not found
-		return
+		i.runtimeError(20, fmt.Sprintf("procedure %s not found", source)) // Error: Procedure not found
+		return patistructs.IntValue(0)
+	}
+	if len(arguments) != len(procedure.Parameters) {
+		i.runtimeError(42, "") // Error: Wrong number of arguments
+		return patistructs.IntValue(0)
 	}
 
-	// Set arguments as local variables
-	for _, arg := range arguments {
-		i.variables[arg.Name] = arg.Value
+	// Evaluate every argument in the caller's scope before any parameter is bound
+	values := make([]patistructs.Value, len(arguments))
+	for n, argument := range arguments {
+		values[n] = i.evaluateExpression(argument.Expression)
+		if i.errors.GetCode() != 0 {
+			return patistructs.IntValue(0)
+		}
+	}
+
+	// Remember where to come back to
+	if !i.enterCall(source) {
+		return patistructs.IntValue(0)
+	}
+
+	// Parameters have slots of their own, so they shadow globals of the same name;
+	// saving the caller's values gives every call, including recursive ones, a fresh frame
+	saved := make([]patistructs.Value, len(procedure.Parameters))
+	for n, slot := range procedure.Parameters {
+		saved[n] = i.variables[slot]
+		i.variables[slot] = values[n]
 	}
 
 	// Execute the procedure
-	i.lineStack = append(i.lineStack, i.currentLine)
-	i.currentLine = procedure
+	i.executeLines(procedure.Body)
+	i.leaveCall()
+
+	for n, slot := range procedure.Parameters {
+		i.variables[slot] = saved[n]
+	}
+
+	// A FUNC that reaches '}' without RETURN yields 0 or an empty string
+	result := patistructs.IntValue(0)
+	if procedure.Type == patistructs.VALUE_STRING {
+		result = patistructs.StringValue("")
+	}
+
+	// RETURN and reaching '}' both resume the caller; END keeps unwinding to the top
+	if i.flow == flowReturn {
+		i.flow = flowNext
+		if procedure.Function {
+			result = i.returnValue
+		}
+	}
+	return result
+}
+
+// Helper function to push a call onto lineStack, failing with a stack overflow beyond GosubLimit nested calls
+func (i *Interpreter) enterCall(callee string) bool {
+	limit := patistructs.DefaultGosubLimit
+	if i.options != nil && i.options.GosubLimit > 0 {
+		limit = min(i.options.GosubLimit, patistructs.MaxGosubLimit)
+	}
+	if len(i.lineStack) >= limit {
+		i.runtimeError(56, fmt.Sprintf("stack overflow: more than %d nested calls\n%s", limit, i.callChain(callee)))
+		return false
+	}
+	i.lineStack = append(i.lineStack, callFrame{caller: i.currentLine, callee: callee})
+	return true
+}
+
+// Helper function to pop the innermost call and go back to the line that made it
+func (i *Interpreter) leaveCall() {
+	i.currentLine = i.lineStack[len(i.lineStack)-1].caller
+	i.lineStack = i.lineStack[:len(i.lineStack)-1]
 }
 
-// Execute a RETURN statement
-func (i *Interpreter) executeReturn() {
+// Number of calls shown at each end of the call chain in a stack overflow message
+const callChainEnds = 8
+
+// Helper function to describe the calls in progress, innermost first, folding runs of the same call into one line
+func (i *Interpreter) callChain(callee string) string {
+	frames := append(i.lineStack[:len(i.lineStack):len(i.lineStack)], callFrame{caller: i.currentLine, callee: callee})
+	var entries []string
+	for n := len(frames) - 1; n >= 0; {
+		frame := frames[n]
+		repeats := 1
+		for n-repeats >= 0 && frames[n-repeats] == frame {
+			repeats++
+		}
+		line := 0
+		if frame.caller != nil {
+			line = frame.caller.Line
+		}
+		entry := fmt.Sprintf("  %s called at line %d", frame.callee, line)
+		if repeats > 1 {
+			entry += fmt.Sprintf(" (%d times)", repeats)
+		}
+		entries = append(entries, entry)
+		n -= repeats
+	}
+
+	// Mutual recursion does not fold, so only the innermost and outermost calls are listed
+	if len(entries) > 2*callChainEnds+1 {
+		skipped := fmt.Sprintf("  ... %d more ...", len(entries)-2*callChainEnds)
+		entries = append(append(entries[:callChainEnds:callChainEnds], skipped), entries[len(entries)-callChainEnds:]...)
+	}
+	return "Call chain, innermost first:\n" + strings.Join(entries, "\n")
+}
+
+// Execute a RETURN statement, remembering the value it returns from a FUNC
+func (i *Interpreter) executeReturn(value *patistructs.ExpressionNode) {
 	if len(i.lineStack) == 0 {
-		i.errors.SetCode(15, 0) // Error: No line to return to
+		i.runtimeError(15, "") // Error: No line to return to
 		return
 	}
-	i.currentLine = i.lineStack[len(i.lineStack)-1]
-	i.lineStack = i.lineStack[:len(i.lineStack)-1]
+	if value != nil {
+		i.returnValue = i.evaluateExpression(value)
+		if i.errors.GetCode() != 0 {
+			return
+		}
+	}
+	i.flow = flowReturn
+}
+
+// Helper function to report a runtime error on the current line; an empty message stands for the catalogue one
+func (i *Interpreter) runtimeError(code int, message string) {
+	line := 0
+	if i.currentLine != nil {
+		line = i.currentLine.Line
+	}
+	i.errors.Report(patistructs.Diagnostic{Severity: patistructs.SEVERITY_ERROR, Code: code, Message: message, Line: line})
+}
+
+// Helper function to convert a numeric value to an integer, truncating floats, with an overflow error if it does not fit
+func (i *Interpreter) integerValue(context string, value patistructs.Value) (int, bool) {
+	n, ok := value.AsInt()
+	if !ok {
+		i.runtimeError(62, fmt.Sprintf("%s: %s is outside the range of integers", context, value))
+	}
+	return n, ok
 }
 
 // Execute an END statement
 func (i *Interpreter) executeEnd() {
-	i.currentLine = nil // End program execution
+	i.flow = flowEnd // End program execution, even from inside a procedure
 }
@@ -0,0 +1,446 @@
+package interpreter
+
+import (
+	"bufio"
+	"fmt"
+	"pati/parser"
+	"pati/patistructs"
+	"pati/tokenizer"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Helper function to run a program with the given answers to INPUT, returning what it printed and its errors as "line: message"
+func run(t *testing.T, source string, input string, configure func(options *patistructs.LanguageOptions)) (string, []string) {
+	t.Helper()
+	options := patistructs.NewLanguageOptions()
+	if configure != nil {
+		configure(options)
+	}
+	diagnostics := patistructs.NewDiagnostics("")
+	program := parser.NewParser(tokenizer.TokenizeWithOptions(source, options), diagnostics, options).ParseProgram()
+	if len(diagnostics.List) > 0 {
+		t.Fatalf("syntax errors: %v", diagnostics.List)
+	}
+
+	var output strings.Builder
+	basicInterpreter := NewInterpreter(diagnostics, options)
+	basicInterpreter.input = bufio.NewReader(strings.NewReader(input))
+	basicInterpreter.output = &output
+	basicInterpreter.RunProgram(program)
+
+	var errors []string
+	for _, diagnostic := range diagnostics.List {
+		errors = append(errors, fmt.Sprintf("%d: %s", diagnostic.Line, diagnostic.Message))
+	}
+	return output.String(), errors
+}
+
+func TestRunProgram(t *testing.T) {
+	tests := []struct {
+		name       string
+		source     string
+		input      string
+		wantOutput string
+		wantErrors []string
+	}{
+		// Operator precedence
+		{
+			name:       "multiplication before addition, and unary minus",
+			source:     "PRINT 2 + 3 * 4 - -1",
+			wantOutput: "15\n",
+		},
+		{
+			name:       "operators of the same level from left to right",
+			source:     "PRINT 10 - 4 - 3\nPRINT 24 / 4 / 2",
+			wantOutput: "3\n3\n",
+		},
+		{
+			name:       "parentheses",
+			source:     "PRINT (2 + 3) * 4\nPRINT -(2 - 5) * (1 + 1)",
+			wantOutput: "20\n6\n",
+		},
+		{
+			name:       "nested parentheses",
+			source:     "PRINT ((1 + 2) * (3 + 4) - 1) * 2",
+			wantOutput: "40\n",
+		},
+
+		// IF
+		{
+			name:       "block IF runs the first branch whose condition holds",
+			source:     "FOR I = 1 TO 4\nIF I = 1 THEN\nPRINT \"one\"\nELSEIF I < 4 THEN\nPRINT \"few\"\nPRINT I\nELSE\nPRINT \"many\"\nEND IF\nNEXT I",
+			wantOutput: "one\nfew\n2\nfew\n3\nmany\n",
+		},
+		{
+			name:       "block IF without a branch that holds",
+			source:     "IF 0 THEN\nPRINT \"then\"\nELSEIF 0 THEN\nPRINT \"elseif\"\nEND IF\nPRINT \"after\"",
+			wantOutput: "after\n",
+		},
+		{
+			name:       "single-line IF runs every statement after THEN",
+			source:     "IF 1 THEN PRINT \"a\": PRINT \"b\" ELSE PRINT \"c\"\nPRINT \"d\"",
+			wantOutput: "a\nb\nd\n",
+		},
+		{
+			name:       "single-line IF runs the ELSE statement",
+			source:     "IF 0 THEN PRINT \"a\": PRINT \"b\" ELSE PRINT \"c\"\nPRINT \"d\"",
+			wantOutput: "c\nd\n",
+		},
+
+		// Logical operators
+		{
+			name:       "AND and OR skip their right side once the result is known",
+			source:     "FUNC Hit(N) {\nPRINT \"called\"\nRETURN N\n}\nIF 0 AND Hit(1) THEN PRINT \"and\"\nIF 1 OR Hit(0) THEN PRINT \"or\"\nPRINT 0 AND Hit(1)\nPRINT 1 OR Hit(1)",
+			wantOutput: "or\n0\n-1\n",
+		},
+		{
+			name:       "AND and OR evaluate their right side when it decides the result",
+			source:     "FUNC Hit(N) {\nPRINT \"called\"\nRETURN N\n}\nIF 1 AND Hit(1) THEN PRINT \"and\"\nIF 0 OR Hit(1) THEN PRINT \"or\"",
+			wantOutput: "called\nand\ncalled\nor\n",
+		},
+		{
+			name:       "comparisons are -1 or 0",
+			source:     "PRINT 1 < 2\nPRINT 2 < 1\nPRINT NOT 0\nPRINT NOT (1 = 1)",
+			wantOutput: "-1\n0\n-1\n0\n",
+		},
+
+		// Jumps
+		{
+			name:       "GOTO a label",
+			source:     "PRINT 1\nGOTO Skip\nPRINT 2\nSkip: PRINT 3",
+			wantOutput: "1\n3\n",
+		},
+		{
+			name:       "GOTO a line number",
+			source:     "10 PRINT 1\n20 GOTO 40\n30 PRINT 2\n40 PRINT 3",
+			wantOutput: "1\n3\n",
+		},
+		{
+			name:       "GOSUB returns to the statement after it",
+			source:     "GOSUB Sub: PRINT \"back\"\nEND\nSub: PRINT \"in\"\nRETURN",
+			wantOutput: "in\nback\n",
+		},
+		{
+			name:       "ON GOTO picks a target by position",
+			source:     "ON 2 GOTO One, Two\nOne: PRINT 1\nTwo: PRINT 2",
+			wantOutput: "2\n",
+		},
+		{
+			name:       "ON GOTO falls through when no target is picked",
+			source:     "FOR I = 0 TO 3 STEP 3\nON I GOTO One, Two\nPRINT \"none\"; I\nNEXT I\nEND\nOne: PRINT 1\nTwo: PRINT 2",
+			wantOutput: "none0\nnone3\n",
+		},
+		{
+			name:       "ON GOSUB",
+			source:     "ON 2 GOSUB One, Two\nPRINT \"back\"\nEND\nOne: PRINT 1\nRETURN\nTwo: PRINT 2\nRETURN",
+			wantOutput: "2\nback\n",
+		},
+		{
+			name:       "RETURN without GOSUB",
+			source:     "PRINT 1\nRETURN",
+			wantOutput: "1\n",
+			wantErrors: []string{"2: RETURN without GOSUB or CALL"},
+		},
+
+		// PRINT
+		{
+			name:       "print zones count characters, not bytes",
+			source:     "PRINT \"é\", 1\nPRINT \"e\", 1",
+			wantOutput: "é             1\ne             1\n",
+		},
+
+		// Procedure calls
+		{
+			name:       "CALL runs the whole body and resumes after the call",
+			source:     "PROC P {\nPRINT \"a\"\nPRINT \"b\"\n}\nCALL P\nPRINT \"c\"",
+			wantOutput: "a\nb\nc\n",
+		},
+		{
+			name:       "RETURN leaves the procedure early",
+			source:     "PROC P {\nPRINT \"a\"\nRETURN\nPRINT \"x\"\n}\nP\nPRINT \"b\"",
+			wantOutput: "a\nb\n",
+		},
+		{
+			name:       "END inside a procedure stops the program",
+			source:     "PROC P {\nPRINT \"a\"\nEND\n}\nP\nPRINT \"b\"",
+			wantOutput: "a\n",
+		},
+		{
+			name:       "nested calls",
+			source:     "PROC A {\nPRINT \"a1\"\nB\nPRINT \"a2\"\n}\nPROC B {\nPRINT \"b\"\n}\nA\nPRINT \"done\"",
+			wantOutput: "a1\nb\na2\ndone\n",
+		},
+		{
+			name:       "recursive procedure",
+			source:     "PROC Count(N) {\nIF N > 0 THEN CALL Count(N - 1)\nPRINT N\n}\nCount(3)",
+			wantOutput: "0\n1\n2\n3\n",
+		},
+		{
+			name:       "recursive function",
+			source:     "FUNC Fact(N) {\nIF N <= 1 THEN RETURN 1\nRETURN N * Fact(N - 1)\n}\nPRINT Fact(5)",
+			wantOutput: "120\n",
+		},
+		{
+			name:       "procedure name before ':' is a call",
+			source:     "PROC Greet {\nPRINT \"hi\"\n}\nGreet: PRINT 2",
+			wantOutput: "hi\n2\n",
+		},
+
+		// INPUT
+		{
+			name:       "answers beyond the variable list are ignored",
+			source:     "INPUT A$\nPRINT A$",
+			input:      "hello, world\n",
+			wantOutput: "? ?Extra ignored\nhello\n",
+		},
+		{
+			name:       "a bad answer asks again",
+			source:     "INPUT A\nPRINT A",
+			input:      "x\n5\n",
+			wantOutput: "? ?Redo from start\n? 5\n",
+		},
+		{
+			name:       "answers spread over several lines",
+			source:     "INPUT \"Pair\"; A, B\nPRINT A + B",
+			input:      "1\n2\n",
+			wantOutput: "Pair? ?? 3\n",
+		},
+
+		// Loops whose condition fails
+		{
+			name:       "WHILE with an unset variable does not run its body",
+			source:     "WHILE I < 2: PRINT \"body\": WEND",
+			wantErrors: []string{"1: variable I not found"},
+		},
+		{
+			name:       "WHILE NOT with an unset variable does not run its body",
+			source:     "WHILE NOT Q: PRINT \"body\": WEND",
+			wantErrors: []string{"1: variable Q not found"},
+		},
+		{
+			name:       "DO WHILE with an unset variable does not run its body",
+			source:     "DO WHILE NOT K: PRINT \"body\": LOOP",
+			wantErrors: []string{"1: variable K not found"},
+		},
+		{
+			name:       "LOOP UNTIL with an unset variable stops the loop",
+			source:     "DO: PRINT \"body\": LOOP UNTIL NOT J",
+			wantOutput: "body\n",
+			wantErrors: []string{"1: variable J not found"},
+		},
+
+		// Runtime errors
+		{
+			name:       "one error per expression",
+			source:     "PRINT Q + Q + Q",
+			wantErrors: []string{"1: variable Q not found"},
+		},
+		{
+			name:       "one error for the arguments of a call",
+			source:     "FUNC F(A, B) {\nRETURN A\n}\nPRINT F(Q * Q, Q)",
+			wantErrors: []string{"4: variable Q not found"},
+		},
+		{
+			name:       "one error for the arguments of a built-in function",
+			source:     "PRINT MAX(Q, Q)",
+			wantErrors: []string{"1: variable Q not found"},
+		},
+		{
+			name:       "variable named as written",
+			source:     "PRINT total + 1",
+			wantErrors: []string{"1: variable total not found"},
+		},
+		{
+			name:       "procedure named as written",
+			source:     "CALL NotHere",
+			wantErrors: []string{"1: procedure NotHere not found"},
+		},
+
+		// Floats too large for an integer
+		{
+			name:       "INT of a float beyond the integer range",
+			source:     "PRINT INT(1E300)",
+			wantErrors: []string{"1: INT: 1e+300 is outside the range of integers"},
+		},
+		{
+			name:       "INT of the lowest integer",
+			source:     "PRINT INT(-9223372036854775808.0)",
+			wantOutput: "-9223372036854775808\n",
+		},
+		{
+			name:       "character count",
+			source:     "PRINT LEFT$(\"abc\", 1E300)",
+			wantErrors: []string{"1: LEFT$: 1e+300 is outside the range of integers"},
+		},
+		{
+			name:       "character code",
+			source:     "PRINT CHR$(-1E300)",
+			wantErrors: []string{"1: CHR$: -1e+300 is outside the range of integers"},
+		},
+		{
+			name:       "DIM bound",
+			source:     "DIM A(1E300)",
+			wantErrors: []string{"1: DIM A: 1e+300 is outside the range of integers"},
+		},
+		{
+			name:       "subscript",
+			source:     "DIM A(3)\nPRINT A(1E19)",
+			wantErrors: []string{"2: subscript of A: 10000000000000000000 is outside the range of integers"},
+		},
+		{
+			name:       "ON selector",
+			source:     "ON 1E300 GOTO Done\nDone: PRINT 1",
+			wantErrors: []string{"1: ON: 1e+300 is outside the range of integers"},
+		},
+		{
+			name:       "MOD",
+			source:     "PRINT 1E300 MOD 7",
+			wantErrors: []string{"1: MOD: 1e+300 is outside the range of integers"},
+		},
+
+		// Arrays
+		{
+			name:       "largest array",
+			source:     "DIM C(1023, 1023)\nLET C(1023, 1023) = 5\nPRINT C(1023, 1023)",
+			wantOutput: "5\n",
+		},
+		{
+			name:       "array size overflowing int",
+			source:     "DIM A(3037000499, 3037000499, 2)",
+			wantErrors: []string{"1: DIM A: array too large, the limit is 1048576 elements"},
+		},
+		{
+			name:       "array beyond the element limit",
+			source:     "DIM B(100000000000)",
+			wantErrors: []string{"1: DIM B: array too large, the limit is 1048576 elements"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output, errors := run(t, test.source, test.input, nil)
+			if output != test.wantOutput {
+				t.Errorf("got output %q, want %q", output, test.wantOutput)
+			}
+			if !reflect.DeepEqual(errors, test.wantErrors) {
+				t.Errorf("got errors %q, want %q", errors, test.wantErrors)
+			}
+		})
+	}
+}
+
+func TestGosubLimit(t *testing.T) {
+	tests := []struct {
+		name  string
+		limit int
+		depth int
+	}{
+		{"default", 0, patistructs.DefaultGosubLimit},
+		{"lowered", 5, 5},
+		{"above the maximum", 100000000, patistructs.MaxGosubLimit},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			source := "PROC recurse {\nCALL Recurse\n}\nCALL reCurse"
+			_, errors := run(t, source, "", func(options *patistructs.LanguageOptions) { options.GosubLimit = test.limit })
+			if len(errors) != 1 {
+				t.Fatalf("got errors %q, want a stack overflow", errors)
+			}
+			want := fmt.Sprintf("2: stack overflow: more than %d nested calls\nCall chain, innermost first:\n  Recurse called at line 2", test.depth)
+			if !strings.HasPrefix(errors[0], want) || !strings.HasSuffix(errors[0], "\n  reCurse called at line 4") {
+				t.Errorf("got %q, want it to start with %q and end with the outermost call", errors[0], want)
+			}
+		})
+	}
+}
+
+func TestBuiltins(t *testing.T) {
+	tests := []struct {
+		expression string
+		want       string
+		wantError  string
+	}{
+		{expression: "ABS(-3)", want: "3"},
+		{expression: "ABS(-2.5)", want: "2.5"},
+		{expression: "SGN(-7)", want: "-1"},
+		{expression: "INT(-2.5)", want: "-3"},
+		{expression: "INT(2.5)", want: "2"},
+		{expression: "SQR(16)", want: "4"},
+		{expression: "SQR(-1)", wantError: "SQR(-1): the argument must not be negative"},
+		{expression: "EXP(0) + LOG(1)", want: "1"},
+		{expression: "LOG(0)", wantError: "LOG(0): the argument must be positive"},
+		{expression: "MIN(3, 1.5, 2)", want: "1.5"},
+		{expression: "MAX(1, 3, 2)", want: "3"},
+
+		{expression: "LEN(\"héllo\")", want: "5"},
+		{expression: "LEFT$(\"héllo\", 2)", want: "hé"},
+		{expression: "RIGHT$(\"héllo\", 3)", want: "llo"},
+		{expression: "LEFT$(\"abc\", 10)", want: "abc"},
+		{expression: "LEFT$(\"abc\", -1)", wantError: "LEFT$: the number of characters -1 must not be negative"},
+		{expression: "MID$(\"héllo\", 2, 3)", want: "éll"},
+		{expression: "MID$(\"héllo\", 4)", want: "lo"},
+		{expression: "MID$(\"héllo\", 9)", want: ""},
+		{expression: "MID$(\"héllo\", 0)", wantError: "MID$: the position 0 must be at least 1"},
+		{expression: "INSTR(\"héllo héllo\", \"llo\")", want: "3"},
+		{expression: "INSTR(4, \"héllo héllo\", \"é\")", want: "8"},
+		{expression: "INSTR(\"héllo\", \"x\")", want: "0"},
+		{expression: "UCASE$(\"héllo\") + LCASE$(\"ÀB\")", want: "HÉLLOàb"},
+		{expression: "TRIM$(\"  a b  \")", want: "a b"},
+		{expression: "STR$(1.5) + \"!\"", want: "1.5!"},
+		{expression: "VAL(\" 12.5abc\")", want: "12.5"},
+		{expression: "CHR$(233)", want: "é"},
+		{expression: "ASC(\"é\")", want: "233"},
+		{expression: "ASC(CHR$(8364))", want: "8364"},
+		{expression: "CHR$(-1)", wantError: "CHR$(-1): -1 is not a character code"},
+		{expression: "ASC(\"\")", wantError: "ASC(\"\"): the string is empty"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expression, func(t *testing.T) {
+			output, errors := run(t, "PRINT "+test.expression, "", nil)
+			if test.wantError != "" {
+				if want := []string{"1: " + test.wantError}; !reflect.DeepEqual(errors, want) {
+					t.Errorf("got errors %q, want %q", errors, want)
+				}
+				return
+			}
+			if len(errors) > 0 {
+				t.Fatalf("unexpected errors %q", errors)
+			}
+			if output != test.want+"\n" {
+				t.Errorf("got %q, want %q", output, test.want+"\n")
+			}
+		})
+	}
+}
+
+func TestRandomize(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+	}{
+		{"the same seed gives the same numbers", "RANDOMIZE 42\nLET A = RND\nLET B = RND\nRANDOMIZE 42\nPRINT A = RND AND B = RND"},
+		{"1 and 1.0 are the same seed", "RANDOMIZE 1\nLET A = RND\nRANDOMIZE 1.0\nPRINT A = RND"},
+		{"a negative argument to RND restarts the sequence", "LET A = RND(-5)\nLET B = RND\nLET C = RND(-5)\nPRINT A = C AND B = RND"},
+		{"RND(0) repeats the last number", "LET A = RND\nPRINT A = RND(0)"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output, errors := run(t, test.source, "", nil)
+			if output != "-1\n" || len(errors) > 0 {
+				t.Errorf("got output %q and errors %q, want -1", output, errors)
+			}
+		})
+	}
+
+	// Until RANDOMIZE, every run gives the same numbers
+	first, _ := run(t, "PRINT RND", "", nil)
+	second, _ := run(t, "PRINT RND", "", nil)
+	if first != second {
+		t.Errorf("two runs printed %q and %q", first, second)
+	}
+}
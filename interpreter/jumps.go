@@ -0,0 +1,64 @@
+package interpreter
+
+import (
+	"pati/patistructs"
+	"strconv"
+)
+
+// Execute a GOTO: leave the line chains up to the one holding the target and carry on from it
+func (i *Interpreter) executeGoto(jumpNode *patistructs.JumpStatementNode) {
+	target := i.jumpDestination(jumpNode)
+	if target == nil {
+		return
+	}
+	i.jumpTarget = target
+	i.flow = flowGoto
+}
+
+// Execute a GOSUB: run the lines from the target until RETURN, then continue after the GOSUB
+func (i *Interpreter) executeGosub(jumpNode *patistructs.JumpStatementNode) {
+	target := i.jumpDestination(jumpNode)
+	if target == nil {
+		return
+	}
+
+	if !i.enterCall("GOSUB " + targetName(target)) {
+		return
+	}
+	i.executeLines(target)
+	i.leaveCall()
+
+	// Falling off the end of the chain also resumes after the GOSUB; END keeps unwinding to the top
+	if i.flow == flowReturn {
+		i.flow = flowNext
+	}
+}
+
+// Helper function to pick the line a jump goes to: its only target, or the one chosen by ON (nil to fall through)
+func (i *Interpreter) jumpDestination(jumpNode *patistructs.JumpStatementNode) *patistructs.ProgramLineNode {
+	if jumpNode == nil || len(jumpNode.Targets) == 0 {
+		return nil
+	}
+	if jumpNode.Selector == nil {
+		return jumpNode.Targets[0]
+	}
+
+	// "ON X GOTO a, b, c" goes to a when X is 1; any other value continues with the next statement
+	value := i.evaluateExpression(jumpNode.Selector)
+	if i.errors.GetCode() != 0 {
+		return nil
+	}
+	selector, ok := i.integerValue("ON", value)
+	if !ok || selector < 1 || selector > len(jumpNode.Targets) {
+		return nil
+	}
+	return jumpNode.Targets[selector-1]
+}
+
+// Helper function to name the line a GOSUB went to by its label, or else its line number
+func targetName(target *patistructs.ProgramLineNode) string {
+	if target.Label != "" {
+		return target.Label
+	}
+	return strconv.Itoa(target.LineNumber)
+}
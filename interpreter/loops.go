@@ -0,0 +1,121 @@
+package interpreter
+
+import (
+	"pati/patistructs"
+)
+
+// Execute a WHILE loop: test the condition before each pass through the body
+func (i *Interpreter) executeWhile(whileNode *patistructs.WhileStatementNode) {
+	if whileNode == nil {
+		return
+	}
+
+	for i.conditionHolds(whileNode.Condition) {
+		i.executeLines(whileNode.Body)
+		if i.flow != flowNext || i.errors.GetCode() != 0 {
+			return
+		}
+	}
+}
+
+// Execute a DO loop, with its optional WHILE/UNTIL tests before and after each pass
+func (i *Interpreter) executeDo(doNode *patistructs.DoStatementNode) {
+	if doNode == nil {
+		return
+	}
+
+	for {
+		if doNode.Pre != nil && !i.loopContinues(doNode.Pre) {
+			return
+		}
+		i.executeLines(doNode.Body)
+		if i.flow == flowExitDo {
+			i.flow = flowNext
+			return
+		}
+		if i.flow != flowNext || i.errors.GetCode() != 0 {
+			return
+		}
+		if doNode.Post != nil && !i.loopContinues(doNode.Post) {
+			return
+		}
+	}
+}
+
+// Execute a FOR loop; the limit and step are evaluated once, before the first pass
+func (i *Interpreter) executeFor(forNode *patistructs.ForStatementNode) {
+	if forNode == nil {
+		return
+	}
+
+	start := i.evaluateExpression(forNode.Start)
+	limit := i.evaluateExpression(forNode.Limit)
+	step := patistructs.IntValue(1)
+	if forNode.Step != nil {
+		step = i.evaluateExpression(forNode.Step)
+	}
+	if i.errors.GetCode() != 0 {
+		return
+	}
+	if !start.IsNumeric() || !limit.IsNumeric() || !step.IsNumeric() {
+		i.runtimeError(14, "") // Error: Value of the wrong type
+		return
+	}
+
+	// Count up to the limit with a positive step and down to it with a negative one
+	direction := compareValues(step, patistructs.IntValue(0))
+	i.variables[forNode.Variable] = start
+	for {
+		counter := i.variables[forNode.Variable]
+		if !counter.IsNumeric() {
+			i.runtimeError(14, "") // Error: Value of the wrong type
+			return
+		}
+		if comparison := compareValues(counter, limit); (direction >= 0 && comparison > 0) || (direction < 0 && comparison < 0) {
+			return
+		}
+
+		i.executeLines(forNode.Body)
+		if i.flow == flowExitFor {
+			i.flow = flowNext
+			return
+		}
+		if i.flow != flowNext || i.errors.GetCode() != 0 {
+			return
+		}
+
+		i.variables[forNode.Variable] = numericOperation(i.variables[forNode.Variable], step,
+			func(a, b int) int { return a + b },
+			func(a, b float64) float64 { return a + b })
+	}
+}
+
+// Execute EXIT FOR or EXIT DO by leaving the line chains up to the innermost loop of that kind
+func (i *Interpreter) executeExit(loop patistructs.StatementClass) {
+	if loop == patistructs.STATEMENT_FOR {
+		i.flow = flowExitFor
+	} else {
+		i.flow = flowExitDo
+	}
+}
+
+// Helper function to decide whether a DO loop keeps going after its WHILE or UNTIL test
+func (i *Interpreter) loopContinues(test *patistructs.LoopConditionNode) bool {
+	holds := i.conditionHolds(test.Condition)
+	if i.errors.GetCode() != 0 {
+		return false // An UNTIL test that failed to evaluate must not keep the loop going
+	}
+	return holds != test.Until
+}
+
+// Helper function to evaluate the condition of a loop; a condition that fails to evaluate never holds
+func (i *Interpreter) conditionHolds(condition *patistructs.ExpressionNode) bool {
+	if condition == nil {
+		return false
+	}
+	value := i.evaluateExpression(condition)
+	if i.errors.GetCode() != 0 {
+		return false
+	}
+	return value.IsTrue()
+}
@@ -2,88 +2,195 @@ package linter
 
 import (
 	"fmt"
+	"pati/parser"
 	"pati/patistructs"
 	"pati/tokenizer"
+	"strconv"
 )
 
 // Linter struct to hold linter information and warnings
 type Linter struct {
-	warnings         []string
-	declaredVars     map[string]bool // Track declared variables
-	usedVars         map[string]bool // Track used variables
-	procedureNames   map[string]bool // Track declared procedure names
-	calledProcedures map[string]bool // Track called procedure names
+	diagnostics      *patistructs.Diagnostics // Syntax errors from the parser, then warnings from the other checks
+	declaredVars     map[string]bool          // Track declared variables
+	usedVars         map[string]bool          // Track used variables
+	procedureNames   map[string]bool          // Track declared procedure names
+	calledProcedures map[string]bool          // Track called procedure names
+	dimensioned      map[string]bool          // Track arrays declared with DIM
+	arrayReferences  []*patistructs.Token     // Names used with subscripts, in order of appearance
+	jumpTargets      []*patistructs.Token     // Labels and line numbers named by GOTO and GOSUB, in order of appearance
+	options          *patistructs.LanguageOptions
 }
 
 // NewLinter creates a new instance of the Linter
 func NewLinter() *Linter {
 	return &Linter{
-		warnings:         []string{},
+		diagnostics:      patistructs.NewDiagnostics(""),
 		declaredVars:     make(map[string]bool),
 		usedVars:         make(map[string]bool),
 		procedureNames:   make(map[string]bool),
 		calledProcedures: make(map[string]bool),
+		dimensioned:      make(map[string]bool),
+		options:          patistructs.NewLanguageOptions(),
 	}
 }
 
 // Lint checks the program content for issues and returns warnings
 func (l *Linter) Lint(content string) []string {
-	tokens := tokenizer.Tokenize(content)
+	tokens := tokenizer.TokenizeWithOptions(content, l.options)
+	l.checkParse(tokens)
 	l.checkSyntax(tokens)
 	l.checkVariableUsage()
+	l.checkArrayUsage()
 	l.checkProcedureDeclarations()
-	l.checkKeywordUsage(tokens)
 	l.checkUnreachableCode(tokens)
-	l.checkTypeMismatch(tokens)
 
-	return l.warnings
+	warnings := []string{}
+	for _, diagnostic := range l.diagnostics.List {
+		if diagnostic.Severity == patistructs.SEVERITY_ERROR {
+			warnings = append(warnings, fmt.Sprintf("Syntax error at line %d, column %d: %s", diagnostic.Line, diagnostic.Column, diagnostic.Message))
+		} else {
+			warnings = append(warnings, diagnostic.Message)
+		}
+	}
+	return warnings
+}
+
+// Diagnostics returns the syntax errors and warnings found by the last call to Lint
+func (l *Linter) Diagnostics() []patistructs.Diagnostic {
+	return l.diagnostics.List
+}
+
+// SyntaxErrors returns the syntax errors found by the last call to Lint, with their positions
+func (l *Linter) SyntaxErrors() []patistructs.Diagnostic {
+	return l.diagnostics.Errors()
+}
+
+// checkParse runs the parser, which recovers from each syntax error, and reports every error it finds
+func (l *Linter) checkParse(tokens []*patistructs.Token) {
+	parser.NewParser(tokens, l.diagnostics, l.options).ParseProgram()
+	l.diagnostics.Sort() // Only parser errors so far, so the warnings keep the order of the checks
+}
+
+// Helper function to report a warning found at a line, or at line 0 when it concerns the whole program
+func (l *Linter) warn(line int, format string, arguments ...interface{}) {
+	l.diagnostics.Report(patistructs.Diagnostic{
+		Severity: patistructs.SEVERITY_WARNING,
+		Message:  fmt.Sprintf(format, arguments...),
+		Line:     line,
+	})
 }
 
 // checkSyntax analyzes the tokens for syntax issues and captures variables and procedures
 func (l *Linter) checkSyntax(tokens []*patistructs.Token) {
-	var braceCount int
 	var lastToken *patistructs.Token
+	var inParameters bool // Between the parentheses that follow a PROC or FUNC name
+	var inDim bool        // On a DIM line, where names followed by '(' declare arrays
+	var depth int         // Nesting of parentheses on the current line
+	var inJump bool       // After GOTO or GOSUB, where names and numbers are labels and line numbers
+	var inInput bool      // After INPUT, where the names listed are assigned from the answers
 
-	for _, token := range tokens {
-		switch token.Class {
-		case patistructs.TOKEN_LEFT_BRACE:
-			braceCount++
-		case patistructs.TOKEN_RIGHT_BRACE:
-			braceCount--
-			if braceCount < 0 {
-				l.warnings = append(l.warnings, fmt.Sprintf("Unmatched '}' at line %d", token.Line))
-				braceCount = 0
+	// Procedures may be called before they are defined, and their names are never labels
+	for index := 1; index < len(tokens); index++ {
+		if tokens[index].Class == patistructs.TOKEN_VARIABLE && l.isProcedureKeyword(tokens[index-1]) {
+			l.procedureNames[l.options.NormalizeName(tokens[index].Content)] = true
+		}
+	}
+
+	for index, token := range tokens {
+		if index > 0 && tokens[index-1].Line != token.Line {
+			inDim = false
+			depth = 0
+		}
+		if token.Class == patistructs.TOKEN_INPUT || token.Class == patistructs.TOKEN_COLON || token.Class == patistructs.TOKEN_EOL {
+			inInput = token.Class == patistructs.TOKEN_INPUT
+		}
+		if token.Class != patistructs.TOKEN_VARIABLE && token.Class != patistructs.TOKEN_NUMBER && token.Class != patistructs.TOKEN_COMMA {
+			inJump = token.Class == patistructs.TOKEN_GOTO || token.Class == patistructs.TOKEN_GOSUB
+		}
+		if l.isTargetDefinition(tokens, index) {
+			continue
+		}
+		if _, builtin := patistructs.Builtins[l.options.NormalizeName(token.Content)]; builtin && token.Class == patistructs.TOKEN_VARIABLE {
+			// Built-in functions like ABS(X) and RND are neither variables nor arrays
+			lastToken = nil
+			continue
+		}
+
+		// A name followed by '(' is an array element or a FUNC call, not a plain variable
+		if token.Class == patistructs.TOKEN_VARIABLE && !inParameters && index+1 < len(tokens) &&
+			tokens[index+1].Class == patistructs.TOKEN_LEFT_PARENTHESIS && (lastToken == nil || lastToken.Class != patistructs.TOKEN_WORD) {
+			if inDim && depth == 0 {
+				l.dimensioned[l.options.NormalizeName(token.Content)] = true
+			} else {
+				l.arrayReferences = append(l.arrayReferences, token)
 			}
-		case patistructs.TOKEN_LET:
+			lastToken = nil
+			continue
+		}
+
+		switch token.Class {
+		case patistructs.TOKEN_DIM:
+			inDim = true
+		case patistructs.TOKEN_LEFT_PARENTHESIS:
+			depth++
+			inParameters = index >= 2 && tokens[index-1].Class == patistructs.TOKEN_VARIABLE && l.isProcedureKeyword(tokens[index-2])
+		case patistructs.TOKEN_RIGHT_PARENTHESIS:
+			depth--
+			inParameters = false
+		case patistructs.TOKEN_LET, patistructs.TOKEN_FOR:
 			// Next token should be a variable
 			lastToken = token
+		case patistructs.TOKEN_NUMBER:
+			if inJump {
+				l.jumpTargets = append(l.jumpTargets, token)
+			}
 		case patistructs.TOKEN_VARIABLE:
-			if lastToken != nil && lastToken.Class == patistructs.TOKEN_LET {
-				// Mark variable as declared
-				l.declaredVars[token.Content] = true
+			name := l.options.NormalizeName(token.Content)
+			if inJump {
+				// GOTO and GOSUB name a label, not a variable
+				l.jumpTargets = append(l.jumpTargets, token)
+			} else if inParameters || inInput {
+				// Parameters are assigned by every call, and INPUT assigns the variables it lists
+				l.declaredVars[name] = true
+			} else if lastToken != nil && (lastToken.Class == patistructs.TOKEN_LET || lastToken.Class == patistructs.TOKEN_FOR) {
+				// Mark variable as declared, including the counter of a FOR loop, which the loop itself uses
+				l.declaredVars[name] = true
+				if lastToken.Class == patistructs.TOKEN_FOR {
+					l.usedVars[name] = true
+				}
+			} else if lastToken != nil && l.isProcedureKeyword(lastToken) {
+				// Capture procedure name
+				l.procedureNames[name] = true
+			} else if lastToken != nil && l.options.NormalizeName(lastToken.Content) == "CALL" {
+				// Capture called procedure name
+				l.calledProcedures[name] = true
 			} else {
 				// Mark variable as used
-				l.usedVars[token.Content] = true
+				l.usedVars[name] = true
 			}
 			lastToken = nil
 		case patistructs.TOKEN_WORD:
-			if token.Content == "PROC" {
-				lastToken = token
-			} else if lastToken != nil && lastToken.Content == "PROC" {
-				// Capture procedure name
-				l.procedureNames[token.Content] = true
-			} else if token.Content == "CALL" {
-				lastToken = token
-			} else if lastToken != nil && lastToken.Content == "CALL" {
-				// Capture called procedure name
-				l.calledProcedures[token.Content] = true
-			}
+			// PROC, FUNC and CALL are followed by a procedure name
+			lastToken = token
 		}
 	}
 
-	if braceCount > 0 {
-		l.warnings = append(l.warnings, "Unmatched '{' found in the code")
+	// A procedure name used without CALL, like "Greet" or "Area(3, 4)", is a call rather than a variable
+	for name := range l.usedVars {
+		if l.procedureNames[name] {
+			l.calledProcedures[name] = true
+			delete(l.usedVars, name)
+		}
+	}
+}
+
+// isProcedureKeyword reports whether a token is PROC or FUNC
+func (l *Linter) isProcedureKeyword(token *patistructs.Token) bool {
+	if token.Class != patistructs.TOKEN_WORD {
+		return false
 	}
+	word := l.options.NormalizeName(token.Content)
+	return word == "PROC" || word == "FUNC"
 }
 
 // checkVariableUsage checks for undeclared or unused variables
@@ -91,16 +198,85 @@ func (l *Linter) checkVariableUsage() {
 	// Check for undeclared variables
 	for variable := range l.usedVars {
 		if !l.declaredVars[variable] {
-			l.warnings = append(l.warnings, fmt.Sprintf("Variable '%s' is used but not declared", variable))
+			l.warn(0, "Variable '%s' is used but not declared", variable)
 		}
 	}
 
 	// Check for unused variables
 	for variable := range l.declaredVars {
 		if !l.usedVars[variable] {
-			l.warnings = append(l.warnings, fmt.Sprintf("Variable '%s' is declared but not used", variable))
+			l.warn(0, "Variable '%s' is declared but not used", variable)
+		}
+	}
+}
+
+// checkArrayUsage checks that every array is dimensioned with DIM somewhere in the program
+func (l *Linter) checkArrayUsage() {
+	reported := make(map[string]bool)
+	for _, token := range l.arrayReferences {
+		name := l.options.NormalizeName(token.Content)
+		if l.procedureNames[name] {
+			// "Area(3, 4)" calls a FUNC
+			l.calledProcedures[name] = true
+			continue
+		}
+		if !l.dimensioned[name] && !reported[name] {
+			l.warn(token.Line, "Array '%s' is used at line %d but never dimensioned with DIM", name, token.Line)
+			reported[name] = true
+		}
+	}
+}
+
+// isTargetDefinition reports whether the token at index is a line number or a label ("Name:") at the start of a line;
+// like in the parser, "Name:" calls the procedure when a PROC or FUNC has that name
+func (l *Linter) isTargetDefinition(tokens []*patistructs.Token, index int) bool {
+	token := tokens[index]
+	firstOnLine := index == 0 || tokens[index-1].Line != token.Line
+	if token.Class == patistructs.TOKEN_NUMBER {
+		return firstOnLine
+	}
+	if token.Class != patistructs.TOKEN_VARIABLE || index+1 >= len(tokens) ||
+		tokens[index+1].Class != patistructs.TOKEN_COLON || tokens[index+1].Line != token.Line ||
+		l.procedureNames[l.options.NormalizeName(token.Content)] {
+		return false
+	}
+	// A label may follow a line number, as in "20 Done: PRINT A"
+	return firstOnLine || (index == 1 || tokens[index-2].Line != token.Line) && follows(tokens, index, patistructs.TOKEN_NUMBER)
+}
+
+// startsStatement reports whether the token at index is the first of its line or follows ':', after any line number or label
+func (l *Linter) startsStatement(tokens []*patistructs.Token, index int) bool {
+	start := index
+	if start >= 2 && follows(tokens, start, patistructs.TOKEN_COLON) && l.isTargetDefinition(tokens, start-2) {
+		start -= 2 // Skip "Name:"
+	}
+	if start >= 1 && follows(tokens, start, patistructs.TOKEN_NUMBER) && l.isTargetDefinition(tokens, start-1) {
+		start-- // Skip the line number
+	}
+	return start == 0 || tokens[start-1].Line != tokens[index].Line ||
+		tokens[start-1].Class == patistructs.TOKEN_EOL || tokens[start-1].Class == patistructs.TOKEN_COLON
+}
+
+// isJumpTarget reports whether GOTO or GOSUB names the label or line number
+func (l *Linter) isJumpTarget(token *patistructs.Token) bool {
+	name := targetName(token, l.options)
+	for _, target := range l.jumpTargets {
+		if targetName(target, l.options) == name {
+			return true
 		}
 	}
+	return false
+}
+
+// targetName gives the spelling of a label or line number used to match jumps with their targets
+func targetName(token *patistructs.Token, options *patistructs.LanguageOptions) string {
+	if token.Class == patistructs.TOKEN_NUMBER {
+		if number, err := strconv.Atoi(token.Content); err == nil {
+			return strconv.Itoa(number)
+		}
+		return token.Content
+	}
+	return options.NormalizeName(token.Content)
 }
 
 // checkProcedureDeclarations checks for undeclared or misused procedures
@@ -108,114 +284,101 @@ func (l *Linter) checkProcedureDeclarations() {
 	// Check for calls to undeclared procedures
 	for procedure := range l.calledProcedures {
 		if !l.procedureNames[procedure] {
-			l.warnings = append(l.warnings, fmt.Sprintf("Procedure '%s' is called but not declared", procedure))
+			l.warn(0, "Procedure '%s' is called but not declared", procedure)
 		}
 	}
 
 	// Check for unused procedures
 	for procedure := range l.procedureNames {
 		if !l.calledProcedures[procedure] {
-			l.warnings = append(l.warnings, fmt.Sprintf("Procedure '%s' is declared but never called", procedure))
+			l.warn(0, "Procedure '%s' is declared but never called", procedure)
 		}
 	}
 }
 
-// checkKeywordUsage ensures proper usage of keywords (e.g., IF-THEN structure)
-func (l *Linter) checkKeywordUsage(tokens []*patistructs.Token) {
-	var expectThen bool
-
-	for _, token := range tokens {
-		if token.Class == patistructs.TOKEN_IF {
-			expectThen = true
-		} else if token.Class == patistructs.TOKEN_THEN {
-			if !expectThen {
-				l.warnings = append(l.warnings, fmt.Sprintf("'THEN' found without a preceding 'IF' at line %d", token.Line))
-			}
-			expectThen = false
-		}
+// opensBlock reports whether the token at index starts a block: the THEN ending a block IF line, or a loop keyword
+func opensBlock(tokens []*patistructs.Token, index int) bool {
+	switch tokens[index].Class {
+	case patistructs.TOKEN_THEN:
+		// THEN at the end of its line opens a block IF; anything after it makes a single-line IF
+		return isLastOnLine(tokens, index) && lineContains(tokens, index, patistructs.TOKEN_IF) && !lineContains(tokens, index, patistructs.TOKEN_ELSEIF)
+	case patistructs.TOKEN_WHILE:
+		// "DO WHILE" and "LOOP WHILE" are tests of a DO loop
+		return !follows(tokens, index, patistructs.TOKEN_DO) && !follows(tokens, index, patistructs.TOKEN_LOOP)
+	case patistructs.TOKEN_DO, patistructs.TOKEN_FOR:
+		return !follows(tokens, index, patistructs.TOKEN_EXIT)
 	}
+	return false
+}
 
-	if expectThen {
-		l.warnings = append(l.warnings, "Missing 'THEN' after 'IF' statement")
+// closesBlock reports whether the token at index ends a block: END IF, WEND, LOOP or NEXT
+func closesBlock(tokens []*patistructs.Token, index int) bool {
+	switch tokens[index].Class {
+	case patistructs.TOKEN_WEND, patistructs.TOKEN_LOOP, patistructs.TOKEN_NEXT:
+		return true
 	}
+	return isEndIf(tokens, index)
 }
 
-// checkUnreachableCode analyzes the program flow for unreachable code
-func (l *Linter) checkUnreachableCode(tokens []*patistructs.Token) {
-	var endReached bool
+// isLastOnLine reports whether the token at index ends its statement, ignoring a trailing comment
+func isLastOnLine(tokens []*patistructs.Token, index int) bool {
+	if index+1 == len(tokens) || tokens[index+1].Line != tokens[index].Line {
+		return true
+	}
+	next := tokens[index+1].Class
+	return next == patistructs.TOKEN_EOL || next == patistructs.TOKEN_COLON || next == patistructs.TOKEN_REM
+}
 
-	for _, token := range tokens {
-		if token.Class == patistructs.TOKEN_END {
-			endReached = true
-		} else if endReached {
-			l.warnings = append(l.warnings, fmt.Sprintf("Unreachable code detected at line %d", token.Line))
-			break
+// lineContains reports whether a token of the given class appears before index on the same line
+func lineContains(tokens []*patistructs.Token, index int, class patistructs.TokenClass) bool {
+	for i := index - 1; i >= 0 && tokens[i].Line == tokens[index].Line; i-- {
+		if tokens[i].Class == class {
+			return true
 		}
 	}
+	return false
 }
-// checkTypeMismatch checks for potential type mismatches in the program
-func (l *Linter) checkTypeMismatch(tokens []*patistructs.Token) {
-	// Map to keep track of variable types
-	// The type could be "int", "string", or any other data type supported in the BASIC program
-	variableTypes := make(map[string]string)
 
-	var currentAssignmentType string
-	var currentVariable string
-	var lastToken *patistructs.Token
+// follows reports whether the token at index directly follows a token of the given class on the same line
+func follows(tokens []*patistructs.Token, index int, class patistructs.TokenClass) bool {
+	return index > 0 && tokens[index-1].Class == class && tokens[index-1].Line == tokens[index].Line
+}
 
-	for _, token := range tokens {
-		switch token.Class {
-		case patistructs.TOKEN_LET:
-			// If we see a LET statement, the next TOKEN_VARIABLE will be the variable being assigned
-			lastToken = token
-		case patistructs.TOKEN_VARIABLE:
-			if lastToken != nil && lastToken.Class == patistructs.TOKEN_LET {
-				// This variable is being assigned a value
-				currentVariable = token.Content
-				currentAssignmentType = "" // Reset the current assignment type for this variable
-				lastToken = nil
-			} else {
-				// This variable is being used in an expression
-				varName := token.Content
-				if expectedType, exists := variableTypes[varName]; exists {
-					// Check if the current assignment type matches the expected type
-					if currentAssignmentType != "" && currentAssignmentType != expectedType {
-						l.warnings = append(l.warnings, fmt.Sprintf("Type mismatch: Variable '%s' is expected to be of type '%s' but is used as type '%s' at line %d", varName, expectedType, currentAssignmentType, token.Line))
-					}
-				}
-			}
-		case patistructs.TOKEN_NUMBER:
-			// If we encounter a number, it implies an integer assignment
-			currentAssignmentType = "int"
-		case patistructs.TOKEN_STRING:
-			// If we encounter a string, it implies a string assignment
-			currentAssignmentType = "string"
-		case patistructs.TOKEN_EQUAL:
-			// If we see an equals sign, the current variable is being assigned a value
-			lastToken = token
-		case patistructs.TOKEN_PLUS, patistructs.TOKEN_MINUS, patistructs.TOKEN_MULTIPLY, patistructs.TOKEN_DIVIDE:
-			// If we see an arithmetic operator, ensure the operands are integers
-			if currentAssignmentType != "int" {
-				l.warnings = append(l.warnings, fmt.Sprintf("Type mismatch: Arithmetic operations expect integer values, found '%s' at line %d", currentAssignmentType, token.Line))
-			}
-		}
+// isEndIf reports whether the token at index is the END of an END IF
+func isEndIf(tokens []*patistructs.Token, index int) bool {
+	return tokens[index].Class == patistructs.TOKEN_END && index+1 < len(tokens) &&
+		tokens[index+1].Class == patistructs.TOKEN_IF && tokens[index+1].Line == tokens[index].Line
+}
 
-		// If we are at the end of an assignment statement, store the type of the variable
-		if token.Class == patistructs.TOKEN_EOL || token.Class == patistructs.TOKEN_SEMICOLON {
-			if currentVariable != "" && currentAssignmentType != "" {
-				if existingType, exists := variableTypes[currentVariable]; exists {
-					// Check if the type is consistent with previous assignments
-					if existingType != currentAssignmentType {
-						l.warnings = append(l.warnings, fmt.Sprintf("Type mismatch: Variable '%s' was previously assigned as '%s' but now as '%s' at line %d", currentVariable, existingType, currentAssignmentType, token.Line))
-					}
-				} else {
-					// Record the type of the variable if it's the first time it's assigned
-					variableTypes[currentVariable] = currentAssignmentType
-				}
+// checkUnreachableCode analyzes the program flow for unreachable code
+func (l *Linter) checkUnreachableCode(tokens []*patistructs.Token) {
+	var endReached bool
+	var endLine int    // Line of the END or GOTO, whose own target is not unreachable
+	var blockDepth int // Code after an END inside a block IF or loop can still be reached
+
+	for index, token := range tokens {
+		if opensBlock(tokens, index) {
+			blockDepth++
+		} else if closesBlock(tokens, index) {
+			if blockDepth > 0 {
+				blockDepth--
+			}
+		} else if token.Class == patistructs.TOKEN_IF && index > 0 && isEndIf(tokens, index-1) {
+			continue
+		} else if l.isProcedureKeyword(token) || token.Class == patistructs.TOKEN_RIGHT_BRACE {
+			// Procedures are reached through calls, and an END inside one does not affect what follows it
+			endReached = false
+		} else if l.isTargetDefinition(tokens, index) {
+			// A line that GOTO or GOSUB jumps to is reachable again, like a subroutine after END
+			if l.isJumpTarget(token) {
+				endReached = false
 			}
-			// Reset for the next statement
-			currentAssignmentType = ""
-			currentVariable = ""
+		} else if (token.Class == patistructs.TOKEN_END || token.Class == patistructs.TOKEN_GOTO) && blockDepth == 0 && l.startsStatement(tokens, index) {
+			endReached = true
+			endLine = token.Line
+		} else if endReached && token.Line != endLine {
+			l.warn(token.Line, "Unreachable code detected at line %d", token.Line)
+			break
 		}
 	}
 }
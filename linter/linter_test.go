@@ -0,0 +1,101 @@
+package linter
+
+import (
+	"pati/patistructs"
+	"reflect"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   []string
+	}{
+		{
+			name:   "clean program",
+			source: "PROC Greet(N$) {\nPRINT \"Hello \"; N$\n}\nLET A$ = \"Ann\"\nCALL Greet(A$)",
+			want:   []string{},
+		},
+		{
+			name:   "procedure never called",
+			source: "PROC Greet {\nPRINT 1\n}",
+			want:   []string{"Procedure 'GREET' is declared but never called"},
+		},
+		{
+			name:   "procedure called by name before ':'",
+			source: "PROC Greet {\nPRINT 1\n}\nGreet: PRINT 2",
+			want:   []string{},
+		},
+		{
+			name:   "variables read by INPUT are declared",
+			source: "INPUT \"Name\"; N$, AGE\nPRINT N$; AGE",
+			want:   []string{},
+		},
+		{
+			name:   "only the variables INPUT lists are declared",
+			source: "INPUT A: PRINT A + B",
+			want:   []string{"Variable 'B' is used but not declared"},
+		},
+		{
+			name:   "string concatenation",
+			source: "LET A$ = \"a\" + \"b\"\nPRINT A$",
+			want:   []string{},
+		},
+		{
+			name:   "numeric function result assigned like a number",
+			source: "LET X = LEN(\"abc\")\nLET X = 2\nPRINT X",
+			want:   []string{},
+		},
+		{
+			name:   "string assigned to a numeric variable",
+			source: "LET X = \"abc\"\nPRINT X",
+			want:   []string{"Syntax error at line 1, column 5: type mismatch"},
+		},
+		{
+			name:   "unclosed WHILE is reported once",
+			source: "LET I = 0\nWHILE I < 3\nLET I = I + 1",
+			want:   []string{"Syntax error at line 2, column 1: loop is never closed (missing WEND, LOOP or NEXT)"},
+		},
+		{
+			name:   "undefined label is reported once",
+			source: "GOTO Nowhere",
+			want:   []string{"Syntax error at line 1, column 6: undefined label or line number"},
+		},
+		{
+			name:   "unreachable code after END",
+			source: "PRINT 1\nEND\nPRINT 2",
+			want:   []string{"Unreachable code detected at line 3"},
+		},
+		{
+			name:   "code after GOTO is unreachable up to its target",
+			source: "LET A = 1\nPRINT A\nGOTO Skip\nPRINT 2\nSkip: END",
+			want:   []string{"Unreachable code detected at line 4"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := NewLinter().Lint(test.source)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestDiagnosticSeverity(t *testing.T) {
+	linter := NewLinter()
+	linter.Lint("FOR I = 1 TO 3\nNEXT J")
+
+	want := []patistructs.Diagnostic{
+		{Severity: patistructs.SEVERITY_ERROR, Code: 35, Message: "NEXT variable does not match FOR", Line: 2, Column: 6, Span: 1},
+		{Severity: patistructs.SEVERITY_WARNING, Message: "Variable 'J' is used but not declared"},
+	}
+	if got := linter.Diagnostics(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	if got := linter.SyntaxErrors(); !reflect.DeepEqual(got, want[:1]) {
+		t.Errorf("syntax errors: got %+v, want %+v", got, want[:1])
+	}
+}
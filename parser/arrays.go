@@ -0,0 +1,139 @@
+package parser
+
+import (
+	"pati/patistructs"
+	"strings"
+)
+
+// Parse a DIM statement: "DIM <array>(<bound>, ...)[, <array>(<bound>, ...) ...]"
+func (p *Parser) parseDimStatement() *patistructs.StatementNode {
+	dimToken := p.currentToken()
+	p.advance() // Move past the DIM token
+
+	dimNode := &patistructs.DimStatementNode{}
+	for {
+		token := p.currentToken()
+		if token.Class != patistructs.TOKEN_VARIABLE {
+			p.syntaxError(3, token) // Error: Expected variable
+			return nil
+		}
+		array, ok := p.arraySlot(token)
+		if !ok {
+			return nil
+		}
+		p.advance() // Move past the array name
+
+		if p.currentToken().Class != patistructs.TOKEN_LEFT_PARENTHESIS {
+			p.syntaxError(22, p.missingToken()) // Error: Expected expression
+			return nil
+		}
+		bounds, ok := p.parseSubscripts()
+		if !ok || !p.checkSubscripts(array, len(bounds), token) {
+			return nil
+		}
+		p.dimensions[array] = len(bounds)
+		dimNode.Arrays = append(dimNode.Arrays, &patistructs.ArrayDeclarationNode{
+			Array:  array,
+			Base:   p.arrayBase,
+			Bounds: bounds,
+		})
+
+		if token := p.currentToken(); token.Class != patistructs.TOKEN_COMMA || token.Line != dimToken.Line {
+			break
+		}
+		p.advance() // Move past ','
+	}
+
+	return &patistructs.StatementNode{
+		Class:   patistructs.STATEMENT_DIM,
+		DimNode: dimNode,
+	}
+}
+
+// Parse an OPTION BASE statement, which sets the lowest subscript of the arrays dimensioned after it
+func (p *Parser) parseOptionStatement() *patistructs.StatementNode {
+	p.advance() // Move past the OPTION token
+
+	if token := p.currentToken(); token.Class != patistructs.TOKEN_VARIABLE || p.options.NormalizeName(token.Content) != "BASE" {
+		p.syntaxError(47, p.missingToken()) // Error: Expected OPTION BASE 0 or OPTION BASE 1
+		return nil
+	}
+	p.advance() // Move past BASE
+
+	// The base must be settled before the first DIM so every array of the program agrees on it
+	token := p.currentToken()
+	if (token.Content != "0" && token.Content != "1") || token.Class != patistructs.TOKEN_NUMBER || len(p.dimensions) > 0 {
+		p.syntaxError(47, p.missingToken()) // Error: Expected OPTION BASE 0 or OPTION BASE 1
+		return nil
+	}
+	p.arrayBase = int(token.Content[0] - '0')
+	p.advance() // Move past the number
+
+	return &patistructs.StatementNode{
+		Class: patistructs.STATEMENT_OPTION,
+	}
+}
+
+// Parse a reference to an array element: "<array>(<subscript>, ...)"
+func (p *Parser) parseArrayElement() (int, []*patistructs.ExpressionNode, bool) {
+	token := p.currentToken()
+	array, ok := p.arraySlot(token)
+	if !ok {
+		return 0, nil, false
+	}
+	p.advance() // Move past the array name
+
+	indices, ok := p.parseSubscripts()
+	if !ok || !p.checkSubscripts(array, len(indices), token) {
+		return 0, nil, false
+	}
+	return array, indices, true
+}
+
+// Helper function to resolve an array name to its slot, which is separate from a plain variable of the same name
+func (p *Parser) arraySlot(token *patistructs.Token) (int, bool) {
+	if index := strings.IndexByte(token.Content, '$'); index >= 0 && index != len(token.Content)-1 {
+		p.syntaxError(29, token) // Error: '$' is only allowed at the end of a variable name
+		return 0, false
+	}
+	if !p.checkNotBuiltin(token) {
+		return 0, false
+	}
+	// '(' cannot appear in a variable name, so "A()" never clashes with the variable A
+	return p.symbols.Resolve(p.options.NormalizeName(token.Content)+"()", token.Content), true
+}
+
+// Parse a parenthesised list of numeric subscripts or bounds
+func (p *Parser) parseSubscripts() ([]*patistructs.ExpressionNode, bool) {
+	p.advance() // Move past '('
+
+	var indices []*patistructs.ExpressionNode
+	for {
+		index := p.parseNumericExpression()
+		if index == nil {
+			return nil, false
+		}
+		indices = append(indices, index)
+
+		if p.currentToken().Class != patistructs.TOKEN_COMMA {
+			break
+		}
+		p.advance() // Move past ','
+	}
+
+	if p.currentToken().Class != patistructs.TOKEN_RIGHT_PARENTHESIS {
+		p.syntaxError(21, p.missingToken()) // Error: Expected ')'
+		return nil, false
+	}
+	p.advance() // Move past ')'
+	return indices, true
+}
+
+// Helper function to check a subscript count against the DIM of the array, when one has been parsed
+func (p *Parser) checkSubscripts(array int, count int, token *patistructs.Token) bool {
+	if dimensions, exists := p.dimensions[array]; exists && dimensions != count {
+		p.syntaxError(46, token) // Error: Wrong number of subscripts
+		return false
+	}
+	return true
+}
@@ -0,0 +1,61 @@
+package parser
+
+import (
+	"pati/patistructs"
+)
+
+// Parse a call of a built-in function, e.g. "ABS(X)", "MAX(A, B, C)" or "RND"
+func (p *Parser) parseBuiltinCall(builtin *patistructs.BuiltinFunction) ([]*patistructs.ArgumentNode, patistructs.ValueClass, bool) {
+	nameToken := p.currentToken()
+	p.advance() // Move past the function name
+
+	arguments, ok := p.parseArguments()
+	if !ok {
+		return nil, patistructs.VALUE_NONE, false
+	}
+	if !builtin.AcceptsArguments(len(arguments)) {
+		p.syntaxError(42, nameToken) // Error: Wrong number of arguments
+		return nil, patistructs.VALUE_NONE, false
+	}
+
+	// The result of ABS, MIN and MAX has the type of their arguments
+	result := builtin.Result
+	if result == patistructs.VALUE_NONE {
+		result = patistructs.VALUE_INT
+	}
+	for n, argument := range arguments {
+		if !compatibleTypes(argument.Expression.Type, builtin.ParameterType(n, len(arguments))) {
+			p.syntaxError(30, nameToken) // Error: Type mismatch
+			return nil, patistructs.VALUE_NONE, false
+		}
+		if builtin.Result == patistructs.VALUE_NONE {
+			result = numericResultType(result, argument.Expression.Type)
+		}
+	}
+	return arguments, result, true
+}
+
+// Helper function to reject a built-in function name where a variable, array or procedure name belongs
+func (p *Parser) checkNotBuiltin(token *patistructs.Token) bool {
+	if _, exists := patistructs.Builtins[p.options.NormalizeName(token.Content)]; exists {
+		p.syntaxError(57, token) // Error: Name of a built-in function
+		return false
+	}
+	return true
+}
+
+// Parse a RANDOMIZE statement: "RANDOMIZE [<seed>]", where leaving out the seed picks one from the clock
+func (p *Parser) parseRandomizeStatement() *patistructs.StatementNode {
+	token := p.currentToken()
+	p.advance() // Move past the RANDOMIZE token
+
+	statement := &patistructs.StatementNode{
+		Class: patistructs.STATEMENT_RANDOMIZE,
+	}
+	if !p.atEndOfStatement(token.Line) {
+		if statement.Seed = p.parseNumericExpression(); statement.Seed == nil {
+			return nil
+		}
+	}
+	return statement
+}
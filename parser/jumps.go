@@ -0,0 +1,166 @@
+package parser
+
+import (
+	"pati/patistructs"
+	"strconv"
+)
+
+// lineTargets maps labels and line numbers, as text, to the lines they name
+type lineTargets map[string]*patistructs.ProgramLineNode
+
+// pendingJump is a GOTO or GOSUB target waiting for the rest of the program to be parsed
+type pendingJump struct {
+	token  *patistructs.Token             // Label or line number, for error reporting
+	key    string                         // Normalized label, or the line number as text
+	jump   *patistructs.JumpStatementNode // Statement whose target this is
+	index  int                            // Position of the target in jump.Targets
+	blocks []int                          // Chains of lines enclosing the jump, outermost first
+	gosub  bool                           // The target must then be outside every block
+}
+
+// Parse a GOTO or GOSUB statement: "GOTO <label|line number>" or "GOSUB <label|line number>"
+func (p *Parser) parseJumpStatement() *patistructs.StatementNode {
+	jumpToken := p.currentToken()
+	p.advance() // Move past GOTO or GOSUB
+
+	jumpNode := &patistructs.JumpStatementNode{}
+	if !p.parseJumpTarget(jumpNode, jumpToken) {
+		return nil
+	}
+	return p.jumpStatement(jumpNode, jumpToken)
+}
+
+// Parse an ON statement: "ON <selector> GOTO <target>, ..." or "ON <selector> GOSUB <target>, ..."
+func (p *Parser) parseOnStatement() *patistructs.StatementNode {
+	onToken := p.currentToken()
+	p.advance() // Move past the ON token
+
+	jumpNode := &patistructs.JumpStatementNode{
+		Selector: p.parseNumericExpression(),
+	}
+	if jumpNode.Selector == nil {
+		return nil
+	}
+
+	jumpToken := p.currentToken()
+	if jumpToken.Class != patistructs.TOKEN_GOTO && jumpToken.Class != patistructs.TOKEN_GOSUB {
+		p.syntaxError(55, p.missingToken()) // Error: Expected GOTO or GOSUB after ON
+		return nil
+	}
+	p.advance() // Move past GOTO or GOSUB
+
+	for {
+		if !p.parseJumpTarget(jumpNode, jumpToken) {
+			return nil
+		}
+		if token := p.currentToken(); token.Class != patistructs.TOKEN_COMMA || token.Line != onToken.Line {
+			break
+		}
+		p.advance() // Move past ','
+	}
+	return p.jumpStatement(jumpNode, jumpToken)
+}
+
+// Helper function to parse one label or line number and queue it to be resolved after parsing
+func (p *Parser) parseJumpTarget(jumpNode *patistructs.JumpStatementNode, jumpToken *patistructs.Token) bool {
+	token := p.currentToken()
+	var key string
+	switch {
+	case token.Class == patistructs.TOKEN_NUMBER && token.Line == jumpToken.Line:
+		lineNumber, err := strconv.Atoi(token.Content)
+		if err != nil || lineNumber <= 0 {
+			p.syntaxError(28, token) // Error: Invalid line number
+			return false
+		}
+		key = strconv.Itoa(lineNumber)
+	case token.Class == patistructs.TOKEN_VARIABLE && token.Line == jumpToken.Line:
+		key = p.options.NormalizeName(token.Content)
+	default:
+		p.syntaxError(51, p.missingToken()) // Error: Expected label or line number
+		return false
+	}
+	p.advance() // Move past the label or line number
+
+	p.jumps = append(p.jumps, &pendingJump{
+		token:  token,
+		key:    key,
+		jump:   jumpNode,
+		index:  len(jumpNode.Targets),
+		blocks: append([]int(nil), p.blocks...),
+		gosub:  jumpToken.Class == patistructs.TOKEN_GOSUB,
+	})
+	jumpNode.Targets = append(jumpNode.Targets, nil)
+	return true
+}
+
+// Helper function to wrap a parsed jump in a GOTO or GOSUB statement
+func (p *Parser) jumpStatement(jumpNode *patistructs.JumpStatementNode, jumpToken *patistructs.Token) *patistructs.StatementNode {
+	class := patistructs.STATEMENT_GOTO
+	if jumpToken.Class == patistructs.TOKEN_GOSUB {
+		class = patistructs.STATEMENT_GOSUB
+	}
+	return &patistructs.StatementNode{
+		Class:    class,
+		JumpNode: jumpNode,
+	}
+}
+
+// Helper function to remember the label and line number of a line so jumps can find it
+func (p *Parser) defineTargets(lineNode *patistructs.ProgramLineNode, start *patistructs.Token) bool {
+	// Labels and line numbers are local to the main program or the procedure defining them
+	scope := p.blocks[0]
+	if p.targets[scope] == nil {
+		p.targets[scope] = make(lineTargets)
+	}
+
+	var keys []string
+	if lineNode.LineNumber != 0 {
+		keys = append(keys, strconv.Itoa(lineNode.LineNumber))
+	}
+	if lineNode.Label != "" {
+		keys = append(keys, lineNode.Label)
+	}
+	for _, key := range keys {
+		if _, exists := p.targets[scope][key]; exists {
+			p.syntaxError(53, start) // Error: Label or line number defined twice
+			return false
+		}
+		p.targets[scope][key] = lineNode
+	}
+	return true
+}
+
+// Helper function to point every GOTO and GOSUB at its target line once the whole program is parsed
+func (p *Parser) resolveJumps() {
+	for _, pending := range p.jumps {
+		target := p.targets[pending.blocks[0]][pending.key]
+		if target == nil {
+			p.syntaxError(52, pending.token) // Error: Undefined label or line number
+			continue
+		}
+
+		// GOTO may leave blocks but not enter them; a GOSUB subroutine starts outside every block
+		reachable := target.Block == pending.blocks[0]
+		if !pending.gosub {
+			for _, block := range pending.blocks {
+				reachable = reachable || target.Block == block
+			}
+		}
+		if !reachable {
+			p.syntaxError(54, pending.token) // Error: Jump into a block
+			continue
+		}
+		pending.jump.Targets[pending.index] = target
+	}
+}
+
+// Helper to start a new chain of lines, such as a procedure body or the lines of a block
+func (p *Parser) enterBlock() {
+	p.blockCount++
+	p.blocks = append(p.blocks, p.blockCount)
+}
+
+// Helper to return to the chain of lines enclosing the one just parsed
+func (p *Parser) leaveBlock() {
+	p.blocks = p.blocks[:len(p.blocks)-1]
+}
@@ -0,0 +1,204 @@
+package parser
+
+import (
+	"pati/patistructs"
+)
+
+// Parse a WHILE statement: "WHILE <condition>" followed by lines up to WEND
+func (p *Parser) parseWhileStatement() *patistructs.StatementNode {
+	whileToken := p.currentToken()
+	p.advance() // Move past the WHILE token
+
+	whileNode := &patistructs.WhileStatementNode{
+		Condition: p.parseNumericExpression(),
+	}
+	if whileNode.Condition == nil {
+		return nil
+	}
+
+	body, ok := p.parseLoopBody(patistructs.STATEMENT_WHILE, whileToken, patistructs.TOKEN_WEND)
+	if !ok {
+		return nil
+	}
+	whileNode.Body = body
+	p.advance() // Move past the WEND token
+
+	return &patistructs.StatementNode{
+		Class:     patistructs.STATEMENT_WHILE,
+		WhileNode: whileNode,
+	}
+}
+
+// Parse a DO statement: "DO [WHILE|UNTIL <condition>]" followed by lines up to "LOOP [WHILE|UNTIL <condition>]"
+func (p *Parser) parseDoStatement() *patistructs.StatementNode {
+	doToken := p.currentToken()
+	p.advance() // Move past the DO token
+
+	doNode := &patistructs.DoStatementNode{}
+	if !p.atEndOfStatement(doToken.Line) {
+		if doNode.Pre = p.parseLoopCondition(); doNode.Pre == nil {
+			return nil
+		}
+	}
+
+	body, ok := p.parseLoopBody(patistructs.STATEMENT_DO, doToken, patistructs.TOKEN_LOOP)
+	if !ok {
+		return nil
+	}
+	doNode.Body = body
+
+	loopToken := p.currentToken()
+	p.advance() // Move past the LOOP token
+	if !p.atEndOfStatement(loopToken.Line) {
+		if doNode.Post = p.parseLoopCondition(); doNode.Post == nil {
+			return nil
+		}
+	}
+
+	return &patistructs.StatementNode{
+		Class:  patistructs.STATEMENT_DO,
+		DoNode: doNode,
+	}
+}
+
+// Parse the "WHILE <condition>" or "UNTIL <condition>" test of a DO loop
+func (p *Parser) parseLoopCondition() *patistructs.LoopConditionNode {
+	token := p.currentToken()
+	if token.Class != patistructs.TOKEN_WHILE && token.Class != patistructs.TOKEN_UNTIL {
+		p.syntaxError(37, p.missingToken()) // Error: Expected WHILE or UNTIL
+		return nil
+	}
+	p.advance() // Move past WHILE or UNTIL
+
+	condition := p.parseNumericExpression()
+	if condition == nil {
+		return nil
+	}
+	return &patistructs.LoopConditionNode{
+		Until:     token.Class == patistructs.TOKEN_UNTIL,
+		Condition: condition,
+	}
+}
+
+// Parse a FOR statement: "FOR <variable> = <start> TO <limit> [STEP <step>]" followed by lines up to "NEXT [<variable>]"
+func (p *Parser) parseForStatement() *patistructs.StatementNode {
+	forToken := p.currentToken()
+	p.advance() // Move past the FOR token
+
+	variableToken := p.currentToken()
+	if variableToken.Class != patistructs.TOKEN_VARIABLE {
+		p.syntaxError(3, variableToken) // Error: Expected variable
+		return nil
+	}
+	if patistructs.VariableClass(variableToken.Content) == patistructs.VALUE_STRING {
+		p.syntaxError(30, variableToken) // Error: Type mismatch
+		return nil
+	}
+	variable, ok := p.variableIndex(variableToken)
+	if !ok {
+		return nil
+	}
+	forNode := &patistructs.ForStatementNode{
+		Variable: variable,
+	}
+	p.advance() // Move past the variable
+
+	if p.currentToken().Class != patistructs.TOKEN_EQUAL {
+		p.syntaxError(4, p.missingToken()) // Error: Expected '='
+		return nil
+	}
+	p.advance() // Move past the '='
+
+	if forNode.Start = p.parseNumericExpression(); forNode.Start == nil {
+		return nil
+	}
+	if token := p.currentToken(); token.Class != patistructs.TOKEN_TO {
+		p.syntaxError(38, p.missingToken()) // Error: Expected TO
+		return nil
+	}
+	p.advance() // Move past the TO token
+	if forNode.Limit = p.parseNumericExpression(); forNode.Limit == nil {
+		return nil
+	}
+	if p.currentToken().Class == patistructs.TOKEN_STEP {
+		p.advance() // Move past the STEP token
+		if forNode.Step = p.parseNumericExpression(); forNode.Step == nil {
+			return nil
+		}
+	}
+
+	body, ok := p.parseLoopBody(patistructs.STATEMENT_FOR, forToken, patistructs.TOKEN_NEXT)
+	if !ok {
+		return nil
+	}
+	forNode.Body = body
+
+	nextToken := p.currentToken()
+	p.advance() // Move past the NEXT token
+	if token := p.currentToken(); token.Class == patistructs.TOKEN_VARIABLE && token.Line == nextToken.Line {
+		// "NEXT I" must name the variable of the innermost FOR
+		if p.options.NormalizeName(token.Content) != p.options.NormalizeName(variableToken.Content) {
+			p.syntaxError(35, token) // Error: NEXT variable does not match FOR
+			return nil
+		}
+		p.advance() // Move past the variable
+	}
+
+	return &patistructs.StatementNode{
+		Class:   patistructs.STATEMENT_FOR,
+		ForNode: forNode,
+	}
+}
+
+// Parse an EXIT statement: "EXIT FOR" or "EXIT DO", which must be inside a loop of that kind
+func (p *Parser) parseExitStatement() *patistructs.StatementNode {
+	exitToken := p.currentToken()
+	p.advance() // Move past the EXIT token
+
+	var loop patistructs.StatementClass
+	switch p.currentToken().Class {
+	case patistructs.TOKEN_FOR:
+		loop = patistructs.STATEMENT_FOR
+	case patistructs.TOKEN_DO:
+		loop = patistructs.STATEMENT_DO
+	default:
+		p.syntaxError(39, exitToken) // Error: Expected FOR or DO after EXIT
+		return nil
+	}
+	p.advance() // Move past FOR or DO
+
+	if !p.insideLoop(loop) {
+		p.syntaxError(36, exitToken) // Error: EXIT outside a loop of that kind
+		return nil
+	}
+
+	return &patistructs.StatementNode{
+		Class:     patistructs.STATEMENT_EXIT,
+		ExitClass: loop,
+	}
+}
+
+// Helper to parse the body of a loop up to its terminator, which is left unconsumed
+func (p *Parser) parseLoopBody(loop patistructs.StatementClass, opening *patistructs.Token, terminator patistructs.TokenClass) (*patistructs.ProgramLineNode, bool) {
+	p.loops = append(p.loops, loop)
+	defer func() { p.loops = p.loops[:len(p.loops)-1] }()
+
+	body, ok := p.parseBlockLines(func() bool { return p.currentToken().Class == terminator })
+	if !ok {
+		if !p.failed {
+			p.syntaxError(34, opening) // Error: Loop is never closed (missing WEND, LOOP or NEXT)
+		}
+		return nil, false
+	}
+	return body, true
+}
+
+// Helper function to check whether the statement being parsed is inside a loop of the given kind
+func (p *Parser) insideLoop(loop patistructs.StatementClass) bool {
+	for _, enclosing := range p.loops {
+		if enclosing == loop {
+			return true
+		}
+	}
+	return false
+}
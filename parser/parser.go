@@ -2,22 +2,52 @@ package parser
 
 import (
 	"pati/patistructs"
+	"strconv"
+	"strings"
 )
+
 // Parser struct
 type Parser struct {
 	tokens     []*patistructs.Token
 	currentPos int
-	errors     patistructs.ErrorHandler
+	errors     *patistructs.Diagnostics
 	options    *patistructs.LanguageOptions
+	symbols    *patistructs.SymbolTable
+	loops      []patistructs.StatementClass          // Enclosing loops of the statement being parsed, innermost last
+	procedures map[string]*patistructs.ProcedureNode // Every PROC and FUNC, found before parsing
+	procedure  *patistructs.ProcedureNode            // Procedure being parsed (nil in the main program)
+	parameters map[string]int                        // Parameters of the procedure being parsed, by normalized name
+	arrayBase  int                                   // Lowest subscript of arrays dimensioned from here on
+	dimensions map[int]int                           // Number of subscripts of each array dimensioned so far, by slot
+	blocks     []int                                 // Chains of lines enclosing the line being parsed, outermost first
+	blockCount int                                   // Number of chains of lines started so far
+	targets    map[int]lineTargets                   // Labelled and numbered lines of the main program and of each procedure
+	jumps      []*pendingJump                        // GOTO and GOSUB targets to resolve after parsing
+	failed     bool                                  // A syntax error was found and parsing has not yet recovered from it
+	errorLine  int                                   // Line of the last syntax error
+	reported   map[errorPosition]bool                // Positions already reported, so one mistake gives one error
+	orphans    []patistructs.TokenClass              // Keywords of blocks whose opening line failed to parse, innermost last, so their terminators are skipped
+}
+
+// errorPosition is the line and position of a token a syntax error was reported at
+type errorPosition struct {
+	line int
+	pos  int
 }
 
 // NewParser creates a new Parser instance
-func NewParser(tokens []*patistructs.Token, errors patistructs.ErrorHandler, options *patistructs.LanguageOptions) *Parser {
+func NewParser(tokens []*patistructs.Token, errors *patistructs.Diagnostics, options *patistructs.LanguageOptions) *Parser {
 	return &Parser{
 		tokens:     tokens,
 		currentPos: 0,
 		errors:     errors,
 		options:    options,
+		symbols:    patistructs.NewSymbolTable(),
+		procedures: make(map[string]*patistructs.ProcedureNode),
+		arrayBase:  options.ArrayBase,
+		dimensions: make(map[int]int),
+		targets:    make(map[int]lineTargets),
+		reported:   make(map[errorPosition]bool),
 	}
 }
 
@@ -29,6 +59,129 @@ func (p *Parser) currentToken() *patistructs.Token {
 	return &patistructs.Token{Class: patistructs.TOKEN_EOF}
 }
 
+// Helper function to look at the token after the current one
+func (p *Parser) peekToken() *patistructs.Token {
+	if p.currentPos+1 < len(p.tokens) {
+		return p.tokens[p.currentPos+1]
+	}
+	return &patistructs.Token{Class: patistructs.TOKEN_EOF}
+}
+
+// Helper function to report a syntax error at a token, with its column for handlers that keep one
+func (p *Parser) syntaxError(code int, token *patistructs.Token) {
+	if token.Class == patistructs.TOKEN_EOF && len(p.tokens) > 0 {
+		// The end of the program is just past its last token
+		last := p.tokens[len(p.tokens)-1]
+		token = &patistructs.Token{Class: patistructs.TOKEN_EOF, Line: last.Line, Pos: last.Pos + len(last.Content)}
+	}
+	p.failed = true
+	p.errorLine = token.Line
+
+	// An unterminated string, for one, also fails the statement it is in
+	position := errorPosition{line: token.Line, pos: token.Pos}
+	if p.reported[position] {
+		return
+	}
+	p.reported[position] = true
+	p.errors.Report(patistructs.NewDiagnostic(code, token))
+}
+
+// Helper to skip the rest of a statement that failed to parse, so parsing can go on with the next one
+func (p *Parser) recover(start int) {
+	if p.currentPos == start {
+		p.advance() // Parsing would fail on the same token again
+	}
+	for !p.atStatementStart() {
+		p.advance()
+	}
+	p.failed = false
+}
+
+// Helper function to check whether the current token can start a statement: the first token of a line, one after ':', THEN or ELSE, a '}' or the end of the program
+func (p *Parser) atStatementStart() bool {
+	token := p.currentToken()
+	if token.Class == patistructs.TOKEN_EOF || token.Class == patistructs.TOKEN_RIGHT_BRACE || p.currentPos == 0 {
+		return true
+	}
+	previous := p.tokens[p.currentPos-1]
+	return token.Line != previous.Line || previous.Class == patistructs.TOKEN_COLON ||
+		previous.Class == patistructs.TOKEN_THEN || previous.Class == patistructs.TOKEN_ELSE
+}
+
+// Helper function to check whether the statement at start, which failed to parse, opened a block whose terminator would then look unmatched
+func (p *Parser) opensBlock(start int) bool {
+	token := p.tokens[start]
+	switch token.Class {
+	case patistructs.TOKEN_WHILE, patistructs.TOKEN_DO, patistructs.TOKEN_FOR:
+		return true
+	case patistructs.TOKEN_IF:
+		// Only an IF whose line ends with THEN starts a block; recover skips the same tokens, so this adds no extra pass
+		last := token
+		for _, other := range p.tokens[start:] {
+			if other.Line != token.Line || other.Class == patistructs.TOKEN_EOL || other.Class == patistructs.TOKEN_REM {
+				break
+			}
+			last = other
+		}
+		return last.Class == patistructs.TOKEN_THEN
+	}
+	return false
+}
+
+// Helper function to skip a terminator of a block whose opening line failed to parse; ELSE and ELSEIF leave the block open
+func (p *Parser) closeOrphan(opening patistructs.TokenClass, closes bool) bool {
+	if len(p.orphans) == 0 || p.orphans[len(p.orphans)-1] != opening {
+		return false
+	}
+	if closes {
+		p.orphans = p.orphans[:len(p.orphans)-1]
+	}
+	return true
+}
+
+// blockOpeners maps each block terminator to the keyword that opens its block
+var blockOpeners = map[patistructs.TokenClass]patistructs.TokenClass{
+	patistructs.TOKEN_WEND:   patistructs.TOKEN_WHILE,
+	patistructs.TOKEN_LOOP:   patistructs.TOKEN_DO,
+	patistructs.TOKEN_NEXT:   patistructs.TOKEN_FOR,
+	patistructs.TOKEN_ELSE:   patistructs.TOKEN_IF,
+	patistructs.TOKEN_ELSEIF: patistructs.TOKEN_IF,
+}
+
+// Helper function to find where a missing token was expected: the current token if it continues the line, else just past the previous one
+func (p *Parser) missingToken() *patistructs.Token {
+	token := p.currentToken()
+	if p.currentPos == 0 || p.currentPos > len(p.tokens) {
+		return token
+	}
+	previous := p.tokens[p.currentPos-1]
+	if token.Class != patistructs.TOKEN_EOF && token.Line == previous.Line {
+		return token
+	}
+	return &patistructs.Token{Class: patistructs.TOKEN_EOL, Line: previous.Line, Pos: previous.Pos + len(previous.Content)}
+}
+
+// Helper function to check whether a token is the given TOKEN_WORD keyword
+func (p *Parser) isWord(token *patistructs.Token, word string) bool {
+	return token.Class == patistructs.TOKEN_WORD && p.options.NormalizeName(token.Content) == word
+}
+
+// Helper function to resolve a variable token to its slot in the symbol table
+func (p *Parser) variableIndex(token *patistructs.Token) (int, bool) {
+	if index := strings.IndexByte(token.Content, '$'); index >= 0 && index != len(token.Content)-1 {
+		p.syntaxError(29, token) // Error: '$' is only allowed at the end of a variable name
+		return 0, false
+	}
+	if !p.checkNotBuiltin(token) {
+		return 0, false
+	}
+	// Inside a procedure its parameters shadow globals of the same name
+	if slot, exists := p.parameters[p.options.NormalizeName(token.Content)]; exists {
+		return slot, true
+	}
+	return p.symbols.Resolve(p.options.NormalizeName(token.Content), token.Content), true
+}
+
 // Helper function to advance to the next token
 func (p *Parser) advance() {
 	if p.currentPos < len(p.tokens) {
@@ -36,36 +189,34 @@ func (p *Parser) advance() {
 	}
 }
 
-// ParseProgram parses an entire BASIC program
+// ParseProgram parses an entire BASIC program, reporting every syntax error and leaving the statements that failed out of the tree
 func (p *Parser) ParseProgram() *patistructs.ProgramNode {
+	p.declareProcedures()
 	program := &patistructs.ProgramNode{
-		Procedures: make(map[string]*patistructs.ProgramLineNode),
+		Procedures: p.procedures,
+		Symbols:    p.symbols,
 	}
+	defined := make(map[string]bool)
+	p.checkStringLiterals()
+	p.enterBlock() // The main program is a single chain of lines, with procedures in between
 
 	for p.currentToken().Class != patistructs.TOKEN_EOF {
-		if p.currentToken().Class == patistructs.TOKEN_WORD && p.currentToken().Content == "PROC" {
-			// Parse a named procedure
-			p.advance() // Move past "PROC"
-			nameToken := p.currentToken()
-			if nameToken.Class != patistructs.TOKEN_WORD {
-				p.errors.SetCode(16, nameToken.Line) // Error: Expected procedure name
-				return nil
-			}
-			procedureName := nameToken.Content
-			p.advance() // Move past the procedure name
-
-			if p.currentToken().Class != patistructs.TOKEN_LEFT_BRACE {
-				p.errors.SetCode(17, nameToken.Line) // Error: Expected '{'
-				return nil
-			}
-			p.advance() // Move past '{'
-
-			procedureLine := p.parseProgramLinesUntilRightBrace()
-			if procedureLine != nil {
-				program.Procedures[procedureName] = procedureLine
+		if p.atStatementSeparator() {
+			p.advance() // Skip an empty statement
+		} else if p.currentToken().Class == patistructs.TOKEN_REM {
+			p.parseComment()
+		} else if p.isWord(p.currentToken(), "PROC") || p.isWord(p.currentToken(), "FUNC") {
+			// Parse a named procedure; an empty body is still a valid procedure
+			main := p.blocks
+			p.blocks = nil
+			start := p.currentPos
+			if p.parseProcedure(defined) == nil {
+				p.skipProcedure(start)
 			}
+			p.blocks = main
 		} else {
 			// Parse the main program
+			start := p.currentPos
 			line := p.parseProgramLine()
 			if line != nil {
 				if program.Main == nil {
@@ -78,57 +229,158 @@ func (p *Parser) ParseProgram() *patistructs.ProgramNode {
 					current.Next = line
 				}
 			}
+			if line == nil || p.failed {
+				// Skip the rest of the bad statement and go on, so every syntax error is reported
+				p.recover(start)
+			}
 		}
 	}
 
+	// Labels may be used before the line defining them
+	p.resolveJumps()
 	return program
 }
 
+// Helper to report every string literal left without its closing quote, which the tokenizer marks as illegal
+func (p *Parser) checkStringLiterals() {
+	for _, token := range p.tokens {
+		if token.Class == patistructs.TOKEN_ILLEGAL && strings.HasPrefix(token.Content, "\"") {
+			p.syntaxError(59, token) // Error: Unterminated string literal
+		}
+	}
+	p.failed = false // The statements holding them fail again when parsed
+}
+
 // Helper to parse lines until a right brace is found
 func (p *Parser) parseProgramLinesUntilRightBrace() *patistructs.ProgramLineNode {
 	var head, current *patistructs.ProgramLineNode
+	p.enterBlock()
+	defer p.leaveBlock()
+
+	// Blocks whose opening line failed cannot end outside the braces they started in
+	orphans := p.orphans
+	p.orphans = nil
+	defer func() { p.orphans = orphans }()
 
 	for p.currentToken().Class != patistructs.TOKEN_RIGHT_BRACE && p.currentToken().Class != patistructs.TOKEN_EOF {
+		if p.atStatementSeparator() {
+			p.advance() // Skip an empty statement
+			continue
+		}
+		if p.currentToken().Class == patistructs.TOKEN_REM {
+			p.parseComment()
+			continue
+		}
+		start := p.currentPos
 		line := p.parseProgramLine()
-		if line != nil {
-			if head == nil {
-				head = line
-			} else {
-				current.Next = line
-			}
-			current = line
+		if line == nil || p.failed {
+			p.recover(start)
+			continue
+		}
+		if head == nil {
+			head = line
+		} else {
+			current.Next = line
 		}
+		current = line
 	}
 
 	if p.currentToken().Class == patistructs.TOKEN_RIGHT_BRACE {
 		p.advance() // Move past '}'
 	} else {
-		p.errors.SetCode(18, 0) // Error: Expected '}'
+		p.syntaxError(18, p.currentToken()) // Error: Expected '}'
 	}
 
 	return head
 }
 
-
+// Parse a program line: an optional line number and/or label followed by a statement
 func (p *Parser) parseProgramLine() *patistructs.ProgramLineNode {
 	token := p.currentToken()
-	if token.Class != patistructs.TOKEN_WORD {
-		p.errors.SetCode(1, token.Line) // Error: Expected procedure name
+	start := token
+	lineNode := &patistructs.ProgramLineNode{
+		Line:  token.Line,
+		Block: p.blocks[len(p.blocks)-1],
+	}
+	// Only the first statement of a line may have a line number or label
+	lineStart := p.currentPos == 0 || p.tokens[p.currentPos-1].Class == patistructs.TOKEN_EOL
+
+	// Optional classic line number, e.g. "10 PRINT A"
+	if lineStart && token.Class == patistructs.TOKEN_NUMBER {
+		lineNumber, err := strconv.Atoi(token.Content)
+		if err != nil || lineNumber <= 0 {
+			p.syntaxError(28, token) // Error: Invalid line number
+			return nil
+		}
+		lineNode.LineNumber = lineNumber
+		p.advance() // Move past the line number
+		token = p.currentToken()
+	}
+
+	// Optional label, e.g. "Loop: PRINT A"; the name of a PROC or FUNC is a call followed by ':' instead
+	if lineStart && token.Class == patistructs.TOKEN_VARIABLE && p.peekToken().Class == patistructs.TOKEN_COLON &&
+		p.procedures[p.options.NormalizeName(token.Content)] == nil {
+		lineNode.Label = p.options.NormalizeName(token.Content)
+		p.advance() // Move past the label
+		p.advance() // Move past ':'
+	}
+	if !p.defineTargets(lineNode, start) {
 		return nil
 	}
 
-	lineNode := &patistructs.ProgramLineNode{
-		ProcedureName: token.Content,
+	// A line may consist of nothing but a line number or label; a stray ELSE is still parsed, and reported
+	if p.currentToken() != start && p.atEndOfStatement(lineNode.Line) {
+		return lineNode
 	}
-	p.advance() // Move past the procedure name
 
-	statement := p.parseStatement()
-	if statement != nil {
-		lineNode.Statement = statement
+	statementPos := p.currentPos
+	lineNode.Statement = p.parseStatement()
+	if lineNode.Statement == nil {
+		if p.failed && p.errorLine == p.tokens[statementPos].Line && p.opensBlock(statementPos) {
+			// The lines of the block are parsed as if it were not there
+			p.orphans = append(p.orphans, p.tokens[statementPos].Class)
+		}
+		return nil
+	}
+	if !p.endStatement() {
+		return nil
 	}
 	return lineNode
 }
 
+// Helper function to check that a statement ends at the current token, moving past the end of the line or ':' that ends it
+func (p *Parser) endStatement() bool {
+	token := p.currentToken()
+	switch token.Class {
+	case patistructs.TOKEN_EOL, patistructs.TOKEN_COLON:
+		p.advance() // Move past the end of the line or ':'
+		return true
+	case patistructs.TOKEN_EOF, patistructs.TOKEN_REM, patistructs.TOKEN_RIGHT_BRACE:
+		// A comment or the '}' closing a procedure may follow on the same line
+		return true
+	}
+	p.syntaxError(60, token) // Error: Unexpected token after statement
+	return false
+}
+
+// Helper function to check for the end of a line or a ':', either of which separates statements
+func (p *Parser) atStatementSeparator() bool {
+	class := p.currentToken().Class
+	return class == patistructs.TOKEN_EOL || class == patistructs.TOKEN_COLON
+}
+
+// Parse a REM comment, which is skipped unless comments are disabled
+func (p *Parser) parseComment() {
+	start := p.currentPos
+	token := p.currentToken()
+	if !p.options.CommentsEnabled {
+		p.syntaxError(24, token) // Error: Comments are not enabled
+		p.recover(start)         // Go on with the next statement, like after any statement that failed
+		return
+	}
+	p.advance() // Move past the comment
+}
+
 // ParseStatement parses a single statement
 func (p *Parser) parseStatement() *patistructs.StatementNode {
 	token := p.currentToken()
@@ -141,53 +393,134 @@ func (p *Parser) parseStatement() *patistructs.StatementNode {
 		return p.parsePrintStatement()
 	case patistructs.TOKEN_INPUT:
 		return p.parseInputStatement()
+	case patistructs.TOKEN_RETURN:
+		return p.parseReturnStatement()
+	case patistructs.TOKEN_END:
+		return p.parseEndStatement()
+	case patistructs.TOKEN_WHILE:
+		return p.parseWhileStatement()
+	case patistructs.TOKEN_DO:
+		return p.parseDoStatement()
+	case patistructs.TOKEN_FOR:
+		return p.parseForStatement()
+	case patistructs.TOKEN_EXIT:
+		return p.parseExitStatement()
+	case patistructs.TOKEN_DIM:
+		return p.parseDimStatement()
+	case patistructs.TOKEN_GOTO, patistructs.TOKEN_GOSUB:
+		return p.parseJumpStatement()
+	case patistructs.TOKEN_ON:
+		return p.parseOnStatement()
+	case patistructs.TOKEN_WEND, patistructs.TOKEN_LOOP, patistructs.TOKEN_NEXT, patistructs.TOKEN_ELSE, patistructs.TOKEN_ELSEIF:
+		closes := token.Class != patistructs.TOKEN_ELSE && token.Class != patistructs.TOKEN_ELSEIF
+		if p.closeOrphan(blockOpeners[token.Class], closes) {
+			return nil // The terminator of a block whose opening line was already reported
+		}
+		p.syntaxError(33, token) // Error: Block terminator without a matching opening statement
+		return nil
 	case patistructs.TOKEN_WORD:
-		if token.Content == "CALL" {
+		if p.isWord(token, "CALL") {
 			return p.parseCallStatement()
 		}
+		if p.isWord(token, "OPTION") {
+			return p.parseOptionStatement()
+		}
+		if p.isWord(token, "RANDOMIZE") {
+			return p.parseRandomizeStatement()
+		}
+	case patistructs.TOKEN_VARIABLE:
+		// A bare name calls the procedure of that name
+		return p.parseCallStatement()
 	default:
-		p.errors.SetCode(2, token.Line) // Example error code for unrecognized statement
+		p.syntaxError(2, token) // Example error code for unrecognized statement
+		return nil
+	}
+	// Ensure a return value for all cases
+	p.syntaxError(2, token) // Example error code for unrecognized statement
+	return nil
+}
+
+// Parse a RETURN statement, which must give the value to return inside a FUNC and only there
+func (p *Parser) parseReturnStatement() *patistructs.StatementNode {
+	token := p.currentToken()
+	p.advance() // Move past the RETURN token
+
+	function := p.procedure != nil && p.procedure.Function
+	statement := &patistructs.StatementNode{
+		Class: patistructs.STATEMENT_RETURN,
+	}
+	if p.atEndOfStatement(token.Line) {
+		if function {
+			p.syntaxError(44, token) // Error: RETURN in a FUNC needs a value
+			return nil
+		}
+		return statement
+	}
+	if !function {
+		p.syntaxError(43, token) // Error: RETURN with a value outside a FUNC
+		return nil
+	}
+
+	statement.ReturnValue = p.parseExpression()
+	if statement.ReturnValue == nil {
 		return nil
 	}
-		// Ensure a return value for all cases
-		p.errors.SetCode(2, token.Line) // Example error code for unrecognized statement
+	if !compatibleTypes(statement.ReturnValue.Type, p.procedure.Type) {
+		p.syntaxError(30, token) // Error: Type mismatch
+		return nil
+	}
+	return statement
+}
+
+// Parse an END statement, which must not be confused with the END IF block terminator
+func (p *Parser) parseEndStatement() *patistructs.StatementNode {
+	token := p.currentToken()
+	p.advance() // Move past the END token
+
+	if next := p.currentToken(); next.Class == patistructs.TOKEN_IF && next.Line == token.Line {
+		if p.closeOrphan(patistructs.TOKEN_IF, true) {
+			return nil // Ends an IF block whose opening line was already reported
+		}
+		p.syntaxError(27, token) // Error: END IF without a matching IF
 		return nil
+	}
+
+	return &patistructs.StatementNode{
+		Class: patistructs.STATEMENT_END,
+	}
 }
 
-// Parse a CALL statement
+// Parse a CALL statement, written either as "CALL Name" or just "Name"
 func (p *Parser) parseCallStatement() *patistructs.StatementNode {
-	p.advance() // Move past the CALL token
+	if p.currentToken().Class == patistructs.TOKEN_WORD {
+		p.advance() // Move past the CALL token
+	}
 
 	nameToken := p.currentToken()
-	if nameToken.Class != patistructs.TOKEN_WORD {
-		p.errors.SetCode(19, nameToken.Line) // Error: Expected procedure name
+	if nameToken.Class != patistructs.TOKEN_VARIABLE {
+		p.syntaxError(19, nameToken) // Error: Expected procedure name
+		return nil
+	}
+	if !p.checkNotBuiltin(nameToken) {
 		return nil
 	}
-	callName := nameToken.Content
+	callName := p.options.NormalizeName(nameToken.Content)
 	p.advance() // Move past the procedure name
 
-	// Parse arguments (if any)
-	var arguments []*patistructs.ArgumentNode
-	if p.currentToken().Class == patistructs.TOKEN_LEFT_PARENTHESIS {
-		p.advance() // Move past '('
-		for p.currentToken().Class != patistructs.TOKEN_RIGHT_PARENTHESIS {
-			argToken := p.currentToken()
-			argName := argToken.Content
-			var argValue interface{} = argToken.Content // Simplified parsing, handle types appropriately
-			arguments = append(arguments, &patistructs.ArgumentNode{Name: argName, Value: argValue})
-			p.advance() // Move past the argument
-
-			if p.currentToken().Class == patistructs.TOKEN_COMMA {
-				p.advance() // Move past ','
-			}
-		}
-		p.advance() // Move past ')'
+	// Parse arguments (if any); calls to an unknown procedure are reported when they run
+	arguments, ok := p.parseArguments()
+	if !ok {
+		return nil
+	}
+	if procedure, exists := p.procedures[callName]; exists && !p.checkArguments(procedure, arguments, nameToken) {
+		return nil
 	}
 
 	return &patistructs.StatementNode{
-		Class:     patistructs.STATEMENT_CALL,
-		CallName:  callName,
-		Arguments: arguments,
+		Class:      patistructs.STATEMENT_CALL,
+		CallName:   callName,
+		CallSource: nameToken.Content,
+		Arguments:  arguments,
 	}
 }
 
@@ -197,63 +530,229 @@ func (p *Parser) parseLetStatement() *patistructs.StatementNode {
 
 	token := p.currentToken()
 	if token.Class != patistructs.TOKEN_VARIABLE {
-		p.errors.SetCode(3, token.Line) // Error: Expected variable
+		p.syntaxError(3, token) // Error: Expected variable
 		return nil
 	}
 
-	letNode := &patistructs.LetStatementNode{
-		Variable: int(token.Content[0] - 'A'), // Convert 'A' to 0, 'B' to 1, etc.
+	letNode := &patistructs.LetStatementNode{}
+	if p.peekToken().Class == patistructs.TOKEN_LEFT_PARENTHESIS {
+		// Assignment to an array element, e.g. "LET A(I) = 5"
+		array, indices, ok := p.parseArrayElement()
+		if !ok {
+			return nil
+		}
+		letNode.Variable = array
+		letNode.Indices = indices
+	} else {
+		variable, ok := p.variableIndex(token)
+		if !ok {
+			return nil
+		}
+		letNode.Variable = variable
+		p.advance() // Move past the variable
 	}
-	p.advance() // Move past the variable
 
 	if p.currentToken().Class != patistructs.TOKEN_EQUAL {
-		p.errors.SetCode(4, token.Line) // Error: Expected '='
+		p.syntaxError(4, p.missingToken()) // Error: Expected '='
 		return nil
 	}
 	p.advance() // Move past the '='
 
 	letNode.Expression = p.parseExpression()
+	if letNode.Expression == nil {
+		return nil
+	}
+	if !compatibleTypes(letNode.Expression.Type, patistructs.VariableClass(token.Content)) {
+		p.syntaxError(30, token) // Error: Type mismatch
+		return nil
+	}
 	return &patistructs.StatementNode{
 		Class:   patistructs.STATEMENT_LET,
 		LetNode: letNode,
 	}
 }
 
-// Parse an IF statement
+// Parse an IF statement, either "IF cond THEN stmt [ELSE stmt]" on one line or a block ending in END IF
 func (p *Parser) parseIfStatement() *patistructs.StatementNode {
+	ifToken := p.currentToken()
 	p.advance() // Move past the IF token
 
-	ifNode := &patistructs.IfStatementNode{
-		Left: p.parseExpression(),
-	}
-	if ifNode.Left == nil {
+	ifNode := p.parseIfCondition()
+	if ifNode == nil {
 		return nil
 	}
 
-	token := p.currentToken()
-	if !p.isRelationalOperator(token.Class) {
-		p.errors.SetCode(5, token.Line) // Error: Expected relational operator
+	// Nothing after THEN on the same line starts a block IF
+	if p.atEndOfStatement(ifToken.Line) {
+		ifNode.Block = true
+		if !p.parseIfBlock(ifNode, ifToken) {
+			return nil
+		}
+		return &patistructs.StatementNode{
+			Class:  patistructs.STATEMENT_IF,
+			IfNode: ifNode,
+		}
+	}
+
+	ifNode.Statement = p.parseBranch()
+	if ifNode.Statement == nil {
 		return nil
 	}
-	ifNode.Op = p.getRelationalOperator(token.Class)
-	p.advance() // Move past the operator
+	if token := p.currentToken(); token.Class == patistructs.TOKEN_ELSE && token.Line == ifToken.Line {
+		p.advance() // Move past the ELSE token
+		ifNode.Else = p.parseBranch()
+		if ifNode.Else == nil {
+			return nil
+		}
+	}
+	return &patistructs.StatementNode{
+		Class:  patistructs.STATEMENT_IF,
+		IfNode: ifNode,
+	}
+}
+
+// Parse the statements of a single-line IF branch: everything up to ELSE or the end of the line, separated by ':'
+func (p *Parser) parseBranch() *patistructs.StatementNode {
+	first := p.parseStatement()
+	for last := first; last != nil && p.currentToken().Class == patistructs.TOKEN_COLON; last = last.Next {
+		p.advance() // Move past ':'
+		if token := p.currentToken(); token.Class == patistructs.TOKEN_ELSE || endsStatement(token, token.Line) {
+			break // Nothing but a trailing ':'
+		}
+		if last.Next = p.parseStatement(); last.Next == nil {
+			return nil
+		}
+	}
+	return first
+}
 
-	ifNode.Right = p.parseExpression()
-	if ifNode.Right == nil {
+// Parse the "<condition> THEN" part of an IF or ELSEIF
+func (p *Parser) parseIfCondition() *patistructs.IfStatementNode {
+	ifNode := &patistructs.IfStatementNode{
+		Condition: p.parseNumericExpression(),
+	}
+	if ifNode.Condition == nil {
 		return nil
 	}
 
-	if p.currentToken().Class != patistructs.TOKEN_THEN {
-		p.errors.SetCode(6, token.Line) // Error: Expected THEN
+	if token := p.currentToken(); token.Class != patistructs.TOKEN_THEN {
+		p.syntaxError(6, p.missingToken()) // Error: Expected THEN
 		return nil
 	}
 	p.advance() // Move past the THEN token
+	return ifNode
+}
 
-	ifNode.Statement = p.parseStatement()
-	return &patistructs.StatementNode{
-		Class:    patistructs.STATEMENT_IF,
-		IfNode:   ifNode,
+// Parse the body of a block IF and its ELSEIF/ELSE branches, up to and including END IF
+func (p *Parser) parseIfBlock(ifNode *patistructs.IfStatementNode, ifToken *patistructs.Token) bool {
+	var ok bool
+	if ifNode.Then, ok = p.parseBlockLines(p.atIfBranchEnd); !ok {
+		if !p.failed {
+			p.syntaxError(31, ifToken) // Error: IF block without END IF
+		}
+		return false
 	}
+
+	token := p.currentToken()
+	switch token.Class {
+	case patistructs.TOKEN_ELSEIF:
+		p.advance() // Move past the ELSEIF token
+		ifNode.ElseIf = p.parseIfCondition()
+		if ifNode.ElseIf == nil {
+			return false
+		}
+		if !p.atEndOfStatement(token.Line) {
+			p.syntaxError(32, token) // Error: ELSEIF ... THEN must end the line
+			// Its END IF is still to come
+			p.orphans = append(p.orphans, patistructs.TOKEN_IF)
+			return false
+		}
+		ifNode.ElseIf.Block = true
+		return p.parseIfBlock(ifNode.ElseIf, ifToken)
+	case patistructs.TOKEN_ELSE:
+		p.advance() // Move past the ELSE token
+		if !p.atEndOfStatement(token.Line) {
+			p.syntaxError(32, token) // Error: ELSE must be on a line of its own in a block IF
+			// Its END IF is still to come
+			p.orphans = append(p.orphans, patistructs.TOKEN_IF)
+			return false
+		}
+		if ifNode.ElseLines, ok = p.parseBlockLines(p.atEndIf); !ok {
+			if !p.failed {
+				p.syntaxError(31, ifToken) // Error: IF block without END IF
+			}
+			return false
+		}
+	}
+
+	p.advance() // Move past END
+	p.advance() // Move past IF
+	return true
+}
+
+// Helper to parse the lines of a block until atEnd reports its terminator, which is left unconsumed
+func (p *Parser) parseBlockLines(atEnd func() bool) (*patistructs.ProgramLineNode, bool) {
+	var head, current *patistructs.ProgramLineNode
+	p.enterBlock()
+	defer p.leaveBlock()
+
+	for !atEnd() {
+		switch p.currentToken().Class {
+		case patistructs.TOKEN_EOF, patistructs.TOKEN_RIGHT_BRACE:
+			return head, false // The caller reports the missing terminator
+		case patistructs.TOKEN_EOL, patistructs.TOKEN_COLON:
+			p.advance() // Skip an empty statement
+			continue
+		case patistructs.TOKEN_REM:
+			p.parseComment()
+			continue
+		}
+
+		start := p.currentPos
+		line := p.parseProgramLine()
+		if line == nil || p.failed {
+			p.recover(start)
+			continue
+		}
+		if head == nil {
+			head = line
+		} else {
+			current.Next = line
+		}
+		current = line
+	}
+	return head, true
+}
+
+// Helper function to check for "END IF" at the current position
+func (p *Parser) atEndIf() bool {
+	token := p.currentToken()
+	next := p.peekToken()
+	return token.Class == patistructs.TOKEN_END && next.Class == patistructs.TOKEN_IF && next.Line == token.Line
+}
+
+// Helper function to check for the end of a block IF branch: ELSEIF, ELSE or END IF
+func (p *Parser) atIfBranchEnd() bool {
+	class := p.currentToken().Class
+	return class == patistructs.TOKEN_ELSEIF || class == patistructs.TOKEN_ELSE || p.atEndIf()
+}
+
+// Helper function to check whether the current token no longer belongs to a statement started on the given line
+func (p *Parser) atEndOfStatement(line int) bool {
+	return endsStatement(p.currentToken(), line)
+}
+
+// Helper function to check whether a token ends a statement started on the given line
+func endsStatement(token *patistructs.Token, line int) bool {
+	return token.Class == patistructs.TOKEN_EOF || token.Line != line ||
+		token.Class == patistructs.TOKEN_EOL || token.Class == patistructs.TOKEN_COLON ||
+		token.Class == patistructs.TOKEN_RIGHT_BRACE || token.Class == patistructs.TOKEN_REM ||
+		token.Class == patistructs.TOKEN_ELSE // Ends the THEN branch of a single-line IF
+}
+
+// Helper function to check whether a token ends a PRINT item on the given line
+func (p *Parser) endsPrintItem(token *patistructs.Token, line int) bool {
+	return token.Class == patistructs.TOKEN_SEMICOLON || token.Class == patistructs.TOKEN_COMMA || endsStatement(token, line)
 }
 
 // Helper function to check for relational operators
@@ -281,30 +780,464 @@ func (p *Parser) getRelationalOperator(class patistructs.TokenClass) patistructs
 	return patistructs.RELOP_EQUAL // Default case, though it shouldn't occur
 }
 
-// Parse an expression (placeholder for now)
+// Parse an expression: one or more conjunctions joined by OR
 func (p *Parser) parseExpression() *patistructs.ExpressionNode {
-	// Implement expression parsing logic
-	return &patistructs.ExpressionNode{}
+	conjunction := p.parseConjunction()
+	if conjunction == nil {
+		return nil
+	}
+	expression := &patistructs.ExpressionNode{Type: conjunction.Type, First: conjunction}
+
+	for last := conjunction; p.currentToken().Class == patistructs.TOKEN_OR; {
+		token := p.currentToken()
+		p.advance() // Move past OR
+
+		next := p.parseConjunction()
+		if next == nil {
+			return nil
+		}
+		if expression.Type == patistructs.VALUE_STRING || next.Type == patistructs.VALUE_STRING {
+			p.syntaxError(30, token) // Error: Type mismatch
+			return nil
+		}
+		expression.Type = patistructs.VALUE_INT
+		last.Next = next
+		last = next
+	}
+	return expression
+}
+
+// Parse a conjunction: one or more relations joined by AND
+func (p *Parser) parseConjunction() *patistructs.ConjunctionNode {
+	relation := p.parseRelation()
+	if relation == nil {
+		return nil
+	}
+	conjunction := &patistructs.ConjunctionNode{Type: relation.Type, First: relation}
+
+	for last := relation; p.currentToken().Class == patistructs.TOKEN_AND; {
+		token := p.currentToken()
+		p.advance() // Move past AND
+
+		next := p.parseRelation()
+		if next == nil {
+			return nil
+		}
+		if conjunction.Type == patistructs.VALUE_STRING || next.Type == patistructs.VALUE_STRING {
+			p.syntaxError(30, token) // Error: Type mismatch
+			return nil
+		}
+		conjunction.Type = patistructs.VALUE_INT
+		last.Next = next
+		last = next
+	}
+	return conjunction
+}
+
+// Parse a relation: "[NOT] <sum> [<relational operator> <sum>]"
+func (p *Parser) parseRelation() *patistructs.RelationNode {
+	relation := &patistructs.RelationNode{}
+
+	// NOT binds more loosely than the comparison, so "NOT A = B" negates "A = B"
+	negated := false
+	notToken := p.currentToken()
+	for p.currentToken().Class == patistructs.TOKEN_NOT {
+		relation.Not = !relation.Not
+		negated = true
+		p.advance() // Move past NOT
+	}
+
+	relation.Left = p.parseSum()
+	if relation.Left == nil {
+		return nil
+	}
+	relation.Type = relation.Left.Type
+
+	if token := p.currentToken(); p.isRelationalOperator(token.Class) {
+		relation.Op = p.getRelationalOperator(token.Class)
+		p.advance() // Move past the operator
+
+		relation.Right = p.parseSum()
+		if relation.Right == nil {
+			return nil
+		}
+		if !compatibleTypes(relation.Left.Type, relation.Right.Type) {
+			p.syntaxError(30, token) // Error: Type mismatch
+			return nil
+		}
+		relation.Type = patistructs.VALUE_INT
+	}
+
+	if negated {
+		if relation.Type == patistructs.VALUE_STRING {
+			p.syntaxError(30, notToken) // Error: Type mismatch
+			return nil
+		}
+		relation.Type = patistructs.VALUE_INT
+	}
+	return relation
+}
+
+// Helper to parse an expression that must be numeric, such as a condition or the bounds of a FOR loop
+func (p *Parser) parseNumericExpression() *patistructs.ExpressionNode {
+	token := p.currentToken()
+	expression := p.parseExpression()
+	if expression == nil {
+		return nil
+	}
+	if expression.Type == patistructs.VALUE_STRING {
+		p.syntaxError(30, token) // Error: Type mismatch
+		return nil
+	}
+	return expression
+}
+
+// Parse a sum: a term followed by any number of '+' or '-' terms
+func (p *Parser) parseSum() *patistructs.SumNode {
+	term := p.parseTerm()
+	if term == nil {
+		return nil
+	}
+	expression := &patistructs.SumNode{Type: term.Type, Term: term}
+
+	var last *patistructs.RightHandTerm
+	for {
+		token := p.currentToken()
+		var op patistructs.ExpressionOperator
+		switch token.Class {
+		case patistructs.TOKEN_PLUS:
+			op = patistructs.EXPRESSION_OPERATOR_PLUS
+		case patistructs.TOKEN_MINUS:
+			op = patistructs.EXPRESSION_OPERATOR_MINUS
+		default:
+			return expression
+		}
+		p.advance() // Move past the operator
+
+		right := p.parseTerm()
+		if right == nil {
+			return nil
+		}
+		// '+' concatenates two strings or adds two numbers; '-' only subtracts numbers
+		if !compatibleTypes(right.Type, expression.Type) || (op == patistructs.EXPRESSION_OPERATOR_MINUS && expression.Type == patistructs.VALUE_STRING) {
+			p.syntaxError(30, token) // Error: Type mismatch
+			return nil
+		}
+		expression.Type = numericResultType(expression.Type, right.Type)
+		next := &patistructs.RightHandTerm{Op: op, Term: right}
+		if last == nil {
+			expression.Next = next
+		} else {
+			last.Next = next
+		}
+		last = next
+	}
+}
+
+// Parse a term: a factor followed by any number of '*' or '/' factors
+func (p *Parser) parseTerm() *patistructs.TermNode {
+	factor := p.parseFactor()
+	if factor == nil {
+		return nil
+	}
+	term := &patistructs.TermNode{Type: factor.Type, Factor: factor}
+
+	var last *patistructs.RightHandFactor
+	for {
+		token := p.currentToken()
+		var op patistructs.TermOperator
+		switch token.Class {
+		case patistructs.TOKEN_MULTIPLY:
+			op = patistructs.TERM_OPERATOR_MULTIPLY
+		case patistructs.TOKEN_DIVIDE:
+			op = patistructs.TERM_OPERATOR_DIVIDE
+			if !p.options.FloatDivision {
+				op = patistructs.TERM_OPERATOR_INTEGER_DIVIDE
+			}
+		case patistructs.TOKEN_INTEGER_DIVIDE:
+			op = patistructs.TERM_OPERATOR_INTEGER_DIVIDE
+		case patistructs.TOKEN_MOD:
+			op = patistructs.TERM_OPERATOR_MOD
+		default:
+			return term
+		}
+		p.advance() // Move past the operator
+
+		right := p.parseFactor()
+		if right == nil {
+			return nil
+		}
+		if term.Type == patistructs.VALUE_STRING || right.Type == patistructs.VALUE_STRING {
+			p.syntaxError(30, token) // Error: Type mismatch
+			return nil
+		}
+		switch op {
+		case patistructs.TERM_OPERATOR_DIVIDE:
+			term.Type = patistructs.VALUE_FLOAT
+		case patistructs.TERM_OPERATOR_INTEGER_DIVIDE, patistructs.TERM_OPERATOR_MOD:
+			term.Type = patistructs.VALUE_INT
+		default:
+			term.Type = numericResultType(term.Type, right.Type)
+		}
+		next := &patistructs.RightHandFactor{Op: op, Factor: right}
+		if last == nil {
+			term.Next = next
+		} else {
+			last.Next = next
+		}
+		last = next
+	}
+}
+
+// Parse a factor: an optionally signed number, string, variable or parenthesised expression
+func (p *Parser) parseFactor() *patistructs.FactorNode {
+	factor := &patistructs.FactorNode{Sign: 1}
+
+	// Unary plus and minus may be stacked, e.g. "- -A"
+	signed := false
+	for p.currentToken().Class == patistructs.TOKEN_MINUS || p.currentToken().Class == patistructs.TOKEN_PLUS {
+		if p.currentToken().Class == patistructs.TOKEN_MINUS {
+			factor.Sign = -factor.Sign
+		}
+		signed = true
+		p.advance() // Move past the sign
+	}
+
+	token := p.currentToken()
+	switch token.Class {
+	case patistructs.TOKEN_NUMBER:
+		value, ok := numberLiteral(token.Content)
+		if !ok {
+			p.syntaxError(23, token) // Error: Invalid numeric literal
+			return nil
+		}
+		factor.Class = patistructs.FACTOR_VALUE
+		factor.Type = value.Class
+		factor.Value = value
+		p.advance() // Move past the number
+	case patistructs.TOKEN_STRING:
+		factor.Class = patistructs.FACTOR_VALUE
+		factor.Type = patistructs.VALUE_STRING
+		factor.Value = patistructs.StringValue(token.Value)
+		p.advance() // Move past the string
+	case patistructs.TOKEN_VARIABLE:
+		if builtin, exists := patistructs.Builtins[p.options.NormalizeName(token.Content)]; exists {
+			arguments, result, ok := p.parseBuiltinCall(builtin)
+			if !ok {
+				return nil
+			}
+			factor.Class = patistructs.FACTOR_CALL
+			factor.Type = result
+			factor.CallName = builtin.Name
+			factor.CallSource = token.Content
+			factor.Arguments = arguments
+			break
+		}
+		// A FUNC name calls the function; a PROC name followed by '(' is a mistaken attempt to do so
+		if procedure, exists := p.procedures[p.options.NormalizeName(token.Content)]; exists &&
+			(procedure.Function || p.peekToken().Class == patistructs.TOKEN_LEFT_PARENTHESIS) {
+			arguments, ok := p.parseFunctionCall(procedure)
+			if !ok {
+				return nil
+			}
+			factor.Class = patistructs.FACTOR_CALL
+			factor.Type = procedure.Type
+			factor.CallName = procedure.Name
+			factor.CallSource = token.Content
+			factor.Arguments = arguments
+			break
+		}
+		if p.peekToken().Class == patistructs.TOKEN_LEFT_PARENTHESIS {
+			array, indices, ok := p.parseArrayElement()
+			if !ok {
+				return nil
+			}
+			factor.Class = patistructs.FACTOR_ARRAY
+			factor.Type = patistructs.VariableClass(token.Content)
+			factor.Variable = array
+			factor.Indices = indices
+			break
+		}
+		variable, ok := p.variableIndex(token)
+		if !ok {
+			return nil
+		}
+		factor.Class = patistructs.FACTOR_VARIABLE
+		factor.Type = patistructs.VariableClass(token.Content)
+		factor.Variable = variable
+		p.advance() // Move past the variable
+	case patistructs.TOKEN_LEFT_PARENTHESIS:
+		p.advance() // Move past '('
+		expression := p.parseExpression()
+		if expression == nil {
+			return nil
+		}
+		if p.currentToken().Class != patistructs.TOKEN_RIGHT_PARENTHESIS {
+			p.syntaxError(21, p.missingToken()) // Error: Expected ')'
+			return nil
+		}
+		p.advance() // Move past ')'
+		factor.Class = patistructs.FACTOR_EXPRESSION
+		factor.Type = expression.Type
+		factor.Expression = expression
+	default:
+		p.syntaxError(22, p.missingToken()) // Error: Expected expression
+		return nil
+	}
+
+	if signed && factor.Type == patistructs.VALUE_STRING {
+		p.syntaxError(30, token) // Error: Type mismatch
+		return nil
+	}
+	return factor
+}
+
+// Helper function to convert a decimal, "&H" hexadecimal or "&B" binary literal to a value
+func numberLiteral(text string) (patistructs.Value, bool) {
+	if len(text) > 2 && text[0] == '&' {
+		base := 16
+		if text[1] == 'B' || text[1] == 'b' {
+			base = 2
+		}
+		n, err := strconv.ParseInt(text[2:], base, 64)
+		return patistructs.IntValue(int(n)), err == nil
+	}
+	if strings.ContainsAny(text, ".Ee") {
+		f, err := strconv.ParseFloat(text, 64)
+		return patistructs.FloatValue(f), err == nil
+	}
+	n, err := strconv.Atoi(text)
+	return patistructs.IntValue(n), err == nil
+}
+
+// Helper function to check whether two static types can be combined or compared
+func compatibleTypes(a, b patistructs.ValueClass) bool {
+	return (a == patistructs.VALUE_STRING) == (b == patistructs.VALUE_STRING)
+}
+
+// Helper function to get the static type of an arithmetic result: float if either side is a float
+func numericResultType(a, b patistructs.ValueClass) patistructs.ValueClass {
+	if a == patistructs.VALUE_FLOAT || b == patistructs.VALUE_FLOAT {
+		return patistructs.VALUE_FLOAT
+	}
+	return a
 }
 
-// Parse a PRINT statement (simplified for now)
+// Parse a PRINT statement: a list of strings and expressions separated by ';' or ','
 func (p *Parser) parsePrintStatement() *patistructs.StatementNode {
+	line := p.currentToken().Line
 	p.advance() // Move past the PRINT token
 
 	printNode := &patistructs.PrintStatementNode{}
-	// Logic to parse expressions or strings to print
+	var last *patistructs.OutputNode
+	for !p.atEndOfStatement(line) {
+		token := p.currentToken()
+
+		if token.Class == patistructs.TOKEN_SEMICOLON || token.Class == patistructs.TOKEN_COMMA {
+			separator := patistructs.OUTPUT_SEPARATOR_SEMICOLON
+			if token.Class == patistructs.TOKEN_COMMA {
+				separator = patistructs.OUTPUT_SEPARATOR_COMMA
+			}
+			// A separator without a preceding item (e.g. "PRINT ,A" or "PRINT A,,B") gets an empty item
+			if last == nil || last.Separator != patistructs.OUTPUT_SEPARATOR_NONE {
+				empty := &patistructs.OutputNode{Class: patistructs.OUTPUT_NONE}
+				if last == nil {
+					printNode.First = empty
+				} else {
+					last.Next = empty
+				}
+				last = empty
+			}
+			last.Separator = separator
+			p.advance() // Move past the separator
+			continue
+		}
+
+		if last != nil && last.Separator == patistructs.OUTPUT_SEPARATOR_NONE {
+			p.syntaxError(25, token) // Error: Expected ';' or ',' between PRINT items
+			return nil
+		}
+
+		output := &patistructs.OutputNode{}
+		if token.Class == patistructs.TOKEN_STRING && p.endsPrintItem(p.peekToken(), line) {
+			// A lone string literal is printed as is without evaluating an expression
+			output.Class = patistructs.OUTPUT_STRING
+			output.Value = token.Value
+			p.advance() // Move past the string
+		} else {
+			output.Class = patistructs.OUTPUT_EXPRESSION
+			output.Expression = p.parseExpression()
+			if output.Expression == nil {
+				return nil
+			}
+		}
+
+		if last == nil {
+			printNode.First = output
+		} else {
+			last.Next = output
+		}
+		last = output
+	}
+
 	return &patistructs.StatementNode{
 		Class:     patistructs.STATEMENT_PRINT,
 		PrintNode: printNode,
 	}
 }
 
-// Parse an INPUT statement (simplified for now)
+// Parse an INPUT statement: an optional prompt followed by a list of variables
 func (p *Parser) parseInputStatement() *patistructs.StatementNode {
 	p.advance() // Move past the INPUT token
 
-	inputNode := &patistructs.InputStatementNode{}
-	// Logic to parse variable list for input
+	inputNode := &patistructs.InputStatementNode{QuestionMark: true}
+	if token := p.currentToken(); token.Class == patistructs.TOKEN_STRING {
+		inputNode.Prompt = token.Value
+		p.advance() // Move past the prompt
+
+		// "INPUT "Prompt"; A" adds a question mark, "INPUT "Prompt", A" does not
+		switch p.currentToken().Class {
+		case patistructs.TOKEN_SEMICOLON:
+		case patistructs.TOKEN_COMMA:
+			inputNode.QuestionMark = false
+		default:
+			p.syntaxError(26, p.missingToken()) // Error: Expected ';' or ',' after INPUT prompt
+			return nil
+		}
+		p.advance() // Move past the separator
+	}
+
+	inputNode.First = &patistructs.VariableListNode{}
+	for {
+		token := p.currentToken()
+		if token.Class != patistructs.TOKEN_VARIABLE {
+			p.syntaxError(3, token) // Error: Expected variable
+			return nil
+		}
+		var indices []*patistructs.ExpressionNode
+		if p.peekToken().Class == patistructs.TOKEN_LEFT_PARENTHESIS {
+			array, arrayIndices, ok := p.parseArrayElement()
+			if !ok {
+				return nil
+			}
+			inputNode.First.Variables = append(inputNode.First.Variables, array)
+			indices = arrayIndices
+		} else {
+			variable, ok := p.variableIndex(token)
+			if !ok {
+				return nil
+			}
+			inputNode.First.Variables = append(inputNode.First.Variables, variable)
+			p.advance() // Move past the variable
+		}
+		inputNode.First.Indices = append(inputNode.First.Indices, indices)
+
+		if p.currentToken().Class != patistructs.TOKEN_COMMA {
+			break
+		}
+		p.advance() // Move past ','
+	}
+
 	return &patistructs.StatementNode{
 		Class:     patistructs.STATEMENT_INPUT,
 		InputNode: inputNode,
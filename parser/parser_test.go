@@ -0,0 +1,190 @@
+package parser
+
+import (
+	"fmt"
+	"pati/patistructs"
+	"pati/tokenizer"
+	"reflect"
+	"testing"
+)
+
+// Helper function to parse a program, returning its tree and its diagnostics as "line:column: message"
+func parse(source string, configure func(options *patistructs.LanguageOptions)) (*patistructs.ProgramNode, []string) {
+	options := patistructs.NewLanguageOptions()
+	if configure != nil {
+		configure(options)
+	}
+	diagnostics := patistructs.NewDiagnostics("")
+	program := NewParser(tokenizer.TokenizeWithOptions(source, options), diagnostics, options).ParseProgram()
+	diagnostics.Sort()
+	var messages []string
+	for _, diagnostic := range diagnostics.List {
+		messages = append(messages, fmt.Sprintf("%d:%d: %s", diagnostic.Line, diagnostic.Column, diagnostic.Message))
+	}
+	return program, messages
+}
+
+func TestParseProgramErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		source    string
+		configure func(options *patistructs.LanguageOptions)
+		want      []string
+	}{
+		{
+			name:   "valid procedures and calls",
+			source: "PROC Greet(N$) {\n  PRINT \"Hello \"; N$\n  RETURN\n}\nFUNC Twice(N) {\n  RETURN N * 2\n}\nCALL Greet(\"Ann\")\nGreet(\"Bob\")\nPRINT Twice(2)",
+		},
+		{
+			name:   "every bad statement is reported",
+			source: "LET A = \nPRINT (1\nGOTO Nowhere\nPRINT 2",
+			want: []string{
+				"1:8: expected expression",
+				"2:9: expected ')'",
+				"3:6: undefined label or line number",
+			},
+		},
+		{
+			name:   "wrong number of arguments",
+			source: "PROC Greet(A) {\n}\nCALL Greet(1, 2)",
+			want:   []string{"3:6: wrong number of arguments"},
+		},
+		{
+			name:   "RETURN with a value in a PROC",
+			source: "PROC P {\nRETURN 1\n}",
+			want:   []string{"2:1: RETURN with a value outside a FUNC"},
+		},
+		{
+			name:   "unexpected token after statement",
+			source: "LET A = 1 2",
+			want:   []string{"1:11: unexpected token after statement"},
+		},
+		{
+			name:   "unclosed WHILE",
+			source: "LET I = 0\nWHILE I < 3\nLET I = I + 1",
+			want:   []string{"2:1: loop is never closed (missing WEND, LOOP or NEXT)"},
+		},
+		{
+			name:   "NEXT variable does not match",
+			source: "FOR I = 1 TO 2\nNEXT J",
+			want:   []string{"2:6: NEXT variable does not match FOR"},
+		},
+		{
+			name:   "failed WHILE line leaves its WEND alone",
+			source: "WHILE 1 +\nPRINT 1\nWEND",
+			want:   []string{"1:10: expected expression"},
+		},
+		{
+			name:   "failed IF line leaves its ELSE and END IF alone",
+			source: "IF 1 + THEN\nPRINT 1\nELSE\nPRINT 2\nEND IF",
+			want:   []string{"1:8: expected expression"},
+		},
+		{
+			name:   "terminator of another kind of block is still reported",
+			source: "WHILE 1 +\nNEXT\nWEND",
+			want: []string{
+				"1:10: expected expression",
+				"2:1: block terminator without a matching opening statement",
+			},
+		},
+		{
+			name:   "failed line in a procedure leaves no orphan behind",
+			source: "PROC P {\nWHILE 1 +\n}\nWEND",
+			want: []string{
+				"2:10: expected expression",
+				"4:1: block terminator without a matching opening statement",
+			},
+		},
+		{
+			name:   "failed line in the main program leaves no orphan in a procedure",
+			source: "WHILE 1 +\nPROC P {\nWEND\n}\nWEND",
+			want: []string{
+				"1:10: expected expression",
+				"3:1: block terminator without a matching opening statement",
+			},
+		},
+		{
+			name:   "ELSE followed by a statement in a block IF",
+			source: "IF 1 THEN\nELSE PRINT 2\nEND IF",
+			want:   []string{"2:1: ELSE or ELSEIF ... THEN must end the line in a block IF"},
+		},
+		{
+			name:   "jump into a block",
+			source: "GOTO Inside\nWHILE 1\nInside: PRINT 1\nWEND",
+			want:   []string{"1:6: jump into a block"},
+		},
+		{
+			name:   "jump out of a block",
+			source: "WHILE 1\nGOTO Outside\nWEND\nOutside: END",
+		},
+		{
+			name:      "disabled comments are reported and skipped",
+			source:    "REM one\nPRINT 1 REM two\nLET = 3\nREM three",
+			configure: func(options *patistructs.LanguageOptions) { options.CommentsEnabled = false },
+			want: []string{
+				"1:1: comments are not enabled",
+				"2:9: comments are not enabled",
+				"3:5: expected variable",
+				"4:1: comments are not enabled",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, got := parse(test.source, test.configure)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestLabelOrProcedureCall(t *testing.T) {
+	tests := []struct {
+		name      string
+		source    string
+		wantLabel string
+		wantCall  string
+	}{
+		{"label", "Top: PRINT 2", "TOP", ""},
+		{"procedure name", "PROC Greet {\n}\nGreet: PRINT 2", "", "GREET"},
+		{"procedure defined later", "greet: PRINT 2\nPROC Greet {\n}", "", "GREET"},
+		{"procedure name after a line number", "PROC Greet {\n}\n10 Greet: PRINT 2", "", "GREET"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			program, errors := parse(test.source, nil)
+			if len(errors) > 0 {
+				t.Fatalf("unexpected errors %q", errors)
+			}
+			line := program.Main
+			if line.Label != test.wantLabel {
+				t.Errorf("got label %q, want %q", line.Label, test.wantLabel)
+			}
+			call := ""
+			if line.Statement != nil && line.Statement.Class == patistructs.STATEMENT_CALL {
+				call = line.Statement.CallName
+			}
+			if call != test.wantCall {
+				t.Errorf("got call %q, want %q", call, test.wantCall)
+			}
+		})
+	}
+}
+
+func TestCallSource(t *testing.T) {
+	program, errors := parse("PROC Greet {\n}\nFUNC Twice(N) {\nRETURN N * 2\n}\ncall greet\nPRINT twice(1)", nil)
+	if len(errors) > 0 {
+		t.Fatalf("unexpected errors %q", errors)
+	}
+	call := program.Main.Statement
+	if call.CallName != "GREET" || call.CallSource != "greet" {
+		t.Errorf("CALL: got name %q and source %q", call.CallName, call.CallSource)
+	}
+	factor := program.Main.Next.Statement.PrintNode.First.Expression.First.First.Left.Term.Factor
+	if factor.CallName != "TWICE" || factor.CallSource != "twice" {
+		t.Errorf("function call: got name %q and source %q", factor.CallName, factor.CallSource)
+	}
+}
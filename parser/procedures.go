@@ -0,0 +1,188 @@
+package parser
+
+import (
+	"pati/patistructs"
+)
+
+// Helper to find every PROC and FUNC with its parameters before parsing, so calls may come before definitions
+func (p *Parser) declareProcedures() {
+	for index := 0; index+1 < len(p.tokens); index++ {
+		token, nameToken := p.tokens[index], p.tokens[index+1]
+		if (!p.isWord(token, "PROC") && !p.isWord(token, "FUNC")) || nameToken.Class != patistructs.TOKEN_VARIABLE {
+			continue
+		}
+		name := p.options.NormalizeName(nameToken.Content)
+		if _, exists := p.procedures[name]; exists {
+			continue // Reported as a duplicate when the second definition is parsed
+		}
+
+		procedure := &patistructs.ProcedureNode{
+			Name:     name,
+			Function: p.isWord(token, "FUNC"),
+		}
+		if procedure.Function {
+			procedure.Type = patistructs.VariableClass(nameToken.Content)
+		}
+		if index+2 < len(p.tokens) && p.tokens[index+2].Class == patistructs.TOKEN_LEFT_PARENTHESIS {
+			for position := index + 3; position < len(p.tokens) && p.tokens[position].Class == patistructs.TOKEN_VARIABLE; position += 2 {
+				procedure.Parameters = append(procedure.Parameters, p.parameterSlot(name, p.tokens[position]))
+				if position+1 >= len(p.tokens) || p.tokens[position+1].Class != patistructs.TOKEN_COMMA {
+					break
+				}
+			}
+		}
+		p.procedures[name] = procedure
+	}
+}
+
+// Parse a procedure definition: "PROC Name[(param, ...)] { ... }" or "FUNC Name[(param, ...)] { ... }"
+func (p *Parser) parseProcedure(defined map[string]bool) *patistructs.ProcedureNode {
+	p.advance() // Move past "PROC" or "FUNC"
+	nameToken := p.currentToken()
+	if nameToken.Class != patistructs.TOKEN_VARIABLE {
+		p.syntaxError(16, nameToken) // Error: Expected procedure name
+		return nil
+	}
+	if !p.checkNotBuiltin(nameToken) {
+		return nil
+	}
+	name := p.options.NormalizeName(nameToken.Content)
+	if defined[name] {
+		p.syntaxError(40, nameToken) // Error: Procedure defined more than once
+		return nil
+	}
+	defined[name] = true
+	procedure := p.procedures[name]
+	p.advance() // Move past the procedure name
+
+	// Formal parameters, e.g. "PROC Area(W, H)"
+	p.parameters = make(map[string]int)
+	defer func() { p.parameters = nil }()
+	procedure.Parameters = nil
+	if p.currentToken().Class == patistructs.TOKEN_LEFT_PARENTHESIS {
+		p.advance() // Move past '('
+		for p.currentToken().Class != patistructs.TOKEN_RIGHT_PARENTHESIS {
+			token := p.currentToken()
+			if token.Class != patistructs.TOKEN_VARIABLE {
+				p.syntaxError(3, token) // Error: Expected variable
+				return nil
+			}
+			if _, ok := p.variableIndex(token); !ok {
+				return nil
+			}
+			parameterName := p.options.NormalizeName(token.Content)
+			if _, exists := p.parameters[parameterName]; exists {
+				p.syntaxError(41, token) // Error: Parameter listed twice
+				return nil
+			}
+			slot := p.parameterSlot(name, token)
+			p.parameters[parameterName] = slot
+			procedure.Parameters = append(procedure.Parameters, slot)
+			p.advance() // Move past the parameter
+
+			if p.currentToken().Class == patistructs.TOKEN_COMMA {
+				p.advance() // Move past ','
+			} else if p.currentToken().Class != patistructs.TOKEN_RIGHT_PARENTHESIS {
+				p.syntaxError(21, p.missingToken()) // Error: Expected ')'
+				return nil
+			}
+		}
+		p.advance() // Move past ')'
+	}
+
+	for p.currentToken().Class == patistructs.TOKEN_EOL {
+		p.advance() // The '{' may start the next line
+	}
+	if p.currentToken().Class != patistructs.TOKEN_LEFT_BRACE {
+		p.syntaxError(17, p.missingToken()) // Error: Expected '{'
+		return nil
+	}
+	p.advance() // Move past '{'
+
+	p.procedure = procedure
+	defer func() { p.procedure = nil }()
+	procedure.Body = p.parseProgramLinesUntilRightBrace()
+	return procedure
+}
+
+// Helper to skip a procedure whose header failed to parse, up to the '}' closing its body, so the body is not taken for the main program
+func (p *Parser) skipProcedure(start int) {
+	depth := 0
+	for token := p.currentToken(); token.Class != patistructs.TOKEN_EOF; token = p.currentToken() {
+		if depth == 0 && p.currentPos > start && (p.isWord(token, "PROC") || p.isWord(token, "FUNC")) {
+			break // The next procedure starts before a body was found
+		}
+		p.advance()
+		if token.Class == patistructs.TOKEN_LEFT_BRACE {
+			depth++
+		} else if token.Class == patistructs.TOKEN_RIGHT_BRACE {
+			if depth--; depth <= 0 {
+				break
+			}
+		}
+	}
+	p.failed = false
+}
+
+// Helper function to give a parameter a slot of its own, distinct from any global of the same name
+func (p *Parser) parameterSlot(procedure string, token *patistructs.Token) int {
+	// '.' cannot appear in a variable name, so the qualified key never clashes with a global
+	return p.symbols.Resolve(procedure+"."+p.options.NormalizeName(token.Content), token.Content)
+}
+
+// Parse the argument list of a call, "(<expression>, ...)", if there is one
+func (p *Parser) parseArguments() ([]*patistructs.ArgumentNode, bool) {
+	var arguments []*patistructs.ArgumentNode
+	if p.currentToken().Class != patistructs.TOKEN_LEFT_PARENTHESIS {
+		return arguments, true
+	}
+	p.advance() // Move past '('
+
+	for p.currentToken().Class != patistructs.TOKEN_RIGHT_PARENTHESIS {
+		expression := p.parseExpression()
+		if expression == nil {
+			return nil, false
+		}
+		arguments = append(arguments, &patistructs.ArgumentNode{Expression: expression})
+
+		if p.currentToken().Class == patistructs.TOKEN_COMMA {
+			p.advance() // Move past ','
+		} else if p.currentToken().Class != patistructs.TOKEN_RIGHT_PARENTHESIS {
+			p.syntaxError(21, p.missingToken()) // Error: Expected ')'
+			return nil, false
+		}
+	}
+	p.advance() // Move past ')'
+	return arguments, true
+}
+
+// Helper function to check the number and types of the arguments passed to a procedure
+func (p *Parser) checkArguments(procedure *patistructs.ProcedureNode, arguments []*patistructs.ArgumentNode, token *patistructs.Token) bool {
+	if len(arguments) != len(procedure.Parameters) {
+		p.syntaxError(42, token) // Error: Wrong number of arguments
+		return false
+	}
+	for n, argument := range arguments {
+		if !compatibleTypes(argument.Expression.Type, patistructs.VariableClass(p.symbols.Name(procedure.Parameters[n]))) {
+			p.syntaxError(30, token) // Error: Type mismatch
+			return false
+		}
+	}
+	return true
+}
+
+// Parse the name and arguments of a FUNC called inside an expression, e.g. "Area(3, 4)"
+func (p *Parser) parseFunctionCall(procedure *patistructs.ProcedureNode) ([]*patistructs.ArgumentNode, bool) {
+	nameToken := p.currentToken()
+	if !procedure.Function {
+		p.syntaxError(45, nameToken) // Error: A PROC does not return a value
+		return nil, false
+	}
+	p.advance() // Move past the function name
+
+	arguments, ok := p.parseArguments()
+	if !ok || !p.checkArguments(procedure, arguments, nameToken) {
+		return nil, false
+	}
+	return arguments, true
+}
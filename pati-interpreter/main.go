@@ -2,6 +2,7 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
 	"pati/interpreter"
@@ -10,28 +11,29 @@ import (
 	"pati/tokenizer"
 )
 
-// ErrorHandler implementation for the interpreter
-type SimpleErrorHandler struct {
-	errorCode int
-	line      int
-}
-
-func (eh *SimpleErrorHandler) SetCode(errorCode int, line int) {
-	eh.errorCode = errorCode
-	eh.line = line
-}
-
-func (eh *SimpleErrorHandler) GetCode() int {
-	return eh.errorCode
+// Print every diagnostic as "file:line:column: message", in the order they appear in the file
+func printDiagnostics(diagnostics *patistructs.Diagnostics) {
+	diagnostics.Sort()
+	for _, diagnostic := range diagnostics.List {
+		fmt.Println(diagnostic)
+	}
 }
 
 func main() {
-	if len(os.Args) < 2 {
-		fmt.Println("Usage: pati <file.bas>")
+	dump := flag.Bool("dump", false, "print all variables when the program stops")
+	escapes := flag.Bool("escapes", false, "decode \\n, \\t, \\\" and \\\\ in string literals")
+	gosubLimit := flag.Int("gosublimit", patistructs.DefaultGosubLimit, "maximum depth of nested CALL, GOSUB and function calls")
+	flag.Parse()
+	if flag.NArg() < 1 {
+		fmt.Println("Usage: pati [-dump] [-escapes] [-gosublimit n] <file.bas>")
+		return
+	}
+	if *gosubLimit > patistructs.MaxGosubLimit {
+		fmt.Printf("Invalid -gosublimit %d: the maximum is %d\n", *gosubLimit, patistructs.MaxGosubLimit)
 		return
 	}
 
-	fileName := os.Args[1]
+	fileName := flag.Arg(0)
 	content, err := os.ReadFile(fileName)
 	if err != nil {
 		fmt.Printf("Error reading file: %s\n", err)
@@ -39,27 +41,34 @@ func main() {
 	}
 
 	// Tokenize the content of the BASIC file
-	tokens := tokenizer.Tokenize(string(content))
+	options := patistructs.NewLanguageOptions()
+	options.GosubLimit = *gosubLimit
+	options.StringEscapes = *escapes
+	tokens := tokenizer.TokenizeWithOptions(string(content), options)
 
-	// Set up the error handler
-	errorHandler := &SimpleErrorHandler{}
+	// Collect every error with its position
+	diagnostics := patistructs.NewDiagnostics(fileName)
 
 	// Parse the tokens to create a ProgramNode
-	programParser := parser.NewParser(tokens, errorHandler, &patistructs.LanguageOptions{})
+	programParser := parser.NewParser(tokens, diagnostics, options)
 	program := programParser.ParseProgram()
 
-	if errorHandler.GetCode() != 0 {
-		fmt.Printf("Parsing error at line %d: error code %d\n", errorHandler.line, errorHandler.GetCode())
+	if diagnostics.GetCode() != 0 {
+		printDiagnostics(diagnostics)
 		return
 	}
 
 	// Create a new instance of the interpreter
-	basicInterpreter := interpreter.NewInterpreter(errorHandler)
+	basicInterpreter := interpreter.NewInterpreter(diagnostics, options)
 
 	// Run the parsed program
 	basicInterpreter.RunProgram(program)
 
-	if errorHandler.GetCode() != 0 {
-		fmt.Printf("Runtime error at line %d: error code %d\n", errorHandler.line, errorHandler.GetCode())
+	if diagnostics.GetCode() != 0 {
+		printDiagnostics(diagnostics)
+	}
+
+	if *dump {
+		basicInterpreter.DumpVariables(os.Stdout)
 	}
 }
@@ -0,0 +1,64 @@
+package patistructs
+
+// BuiltinFunction describes the signature of a function built into the language
+type BuiltinFunction struct {
+	Name       string
+	Parameters []ValueClass // Type of each argument; VALUE_FLOAT accepts any number
+	Optional   int          // Number of trailing arguments that may be left out
+	Leading    bool         // The first argument may be left out instead, as in INSTR([start,] text$, find$)
+	Variadic   bool         // The last parameter may be repeated
+	Result     ValueClass   // Type of the result; VALUE_NONE means a float if any argument is one, else an integer
+}
+
+// Builtins maps the normalized name of each built-in function to its signature
+var Builtins = map[string]*BuiltinFunction{
+	"ABS": {Name: "ABS", Parameters: []ValueClass{VALUE_FLOAT}, Result: VALUE_NONE},
+	"SGN": {Name: "SGN", Parameters: []ValueClass{VALUE_FLOAT}, Result: VALUE_INT},
+	"INT": {Name: "INT", Parameters: []ValueClass{VALUE_FLOAT}, Result: VALUE_INT},
+	"SQR": {Name: "SQR", Parameters: []ValueClass{VALUE_FLOAT}, Result: VALUE_FLOAT},
+	"SIN": {Name: "SIN", Parameters: []ValueClass{VALUE_FLOAT}, Result: VALUE_FLOAT},
+	"COS": {Name: "COS", Parameters: []ValueClass{VALUE_FLOAT}, Result: VALUE_FLOAT},
+	"ATN": {Name: "ATN", Parameters: []ValueClass{VALUE_FLOAT}, Result: VALUE_FLOAT},
+	"EXP": {Name: "EXP", Parameters: []ValueClass{VALUE_FLOAT}, Result: VALUE_FLOAT},
+	"LOG": {Name: "LOG", Parameters: []ValueClass{VALUE_FLOAT}, Result: VALUE_FLOAT},
+	"RND": {Name: "RND", Parameters: []ValueClass{VALUE_FLOAT}, Optional: 1, Result: VALUE_FLOAT},
+	"MIN": {Name: "MIN", Parameters: []ValueClass{VALUE_FLOAT, VALUE_FLOAT}, Variadic: true, Result: VALUE_NONE},
+	"MAX": {Name: "MAX", Parameters: []ValueClass{VALUE_FLOAT, VALUE_FLOAT}, Variadic: true, Result: VALUE_NONE},
+
+	// String functions count characters rather than bytes, and the positions of MID$ and INSTR start at 1
+	"LEN":    {Name: "LEN", Parameters: []ValueClass{VALUE_STRING}, Result: VALUE_INT},
+	"LEFT$":  {Name: "LEFT$", Parameters: []ValueClass{VALUE_STRING, VALUE_FLOAT}, Result: VALUE_STRING},
+	"RIGHT$": {Name: "RIGHT$", Parameters: []ValueClass{VALUE_STRING, VALUE_FLOAT}, Result: VALUE_STRING},
+	"MID$":   {Name: "MID$", Parameters: []ValueClass{VALUE_STRING, VALUE_FLOAT, VALUE_FLOAT}, Optional: 1, Result: VALUE_STRING},
+	"INSTR":  {Name: "INSTR", Parameters: []ValueClass{VALUE_FLOAT, VALUE_STRING, VALUE_STRING}, Leading: true, Result: VALUE_INT},
+	"UCASE$": {Name: "UCASE$", Parameters: []ValueClass{VALUE_STRING}, Result: VALUE_STRING},
+	"LCASE$": {Name: "LCASE$", Parameters: []ValueClass{VALUE_STRING}, Result: VALUE_STRING},
+	"TRIM$":  {Name: "TRIM$", Parameters: []ValueClass{VALUE_STRING}, Result: VALUE_STRING},
+	"STR$":   {Name: "STR$", Parameters: []ValueClass{VALUE_FLOAT}, Result: VALUE_STRING},
+	"VAL":    {Name: "VAL", Parameters: []ValueClass{VALUE_STRING}, Result: VALUE_FLOAT},
+	"CHR$":   {Name: "CHR$", Parameters: []ValueClass{VALUE_FLOAT}, Result: VALUE_STRING},
+	"ASC":    {Name: "ASC", Parameters: []ValueClass{VALUE_STRING}, Result: VALUE_INT},
+}
+
+// ParameterType returns the type expected for the argument at the given position of a call with count arguments
+func (b *BuiltinFunction) ParameterType(position int, count int) ValueClass {
+	if b.Leading && count < len(b.Parameters) {
+		position++ // The optional first argument was left out
+	}
+	if position >= len(b.Parameters) {
+		return b.Parameters[len(b.Parameters)-1] // Only reachable when Variadic
+	}
+	return b.Parameters[position]
+}
+
+// AcceptsArguments reports whether the function can be called with the given number of arguments
+func (b *BuiltinFunction) AcceptsArguments(count int) bool {
+	required := len(b.Parameters) - b.Optional
+	if b.Leading {
+		required--
+	}
+	if count < required {
+		return false
+	}
+	return b.Variadic || count <= len(b.Parameters)
+}
@@ -0,0 +1,196 @@
+package patistructs
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Severity tells whether a diagnostic stops the program or only points at a likely mistake
+type Severity int
+
+const (
+	SEVERITY_ERROR Severity = iota
+	SEVERITY_WARNING
+)
+
+// String returns the name of the severity as printed before a message
+func (s Severity) String() string {
+	if s == SEVERITY_WARNING {
+		return "warning"
+	}
+	return "error"
+}
+
+// Diagnostic describes one problem found while parsing or running a program
+type Diagnostic struct {
+	Severity Severity
+	Code     int    // Stable numeric code, see ErrorMessages
+	Message  string // Human readable description
+	File     string // Name of the source file, if known
+	Line     int    // Source line, starting at 1; 0 if unknown
+	Column   int    // Byte column within the line, starting at 1; 0 if unknown
+	Span     int    // Number of bytes of source the problem covers; 0 if unknown
+}
+
+// ErrorMessages maps each error code to the message reported when no more specific one is given.
+// Codes 1 and 5 are retired: nothing reports them any more, and they keep their entries so they are never reused.
+var ErrorMessages = map[int]string{
+	1:  "retired: expected procedure name at the start of a line",
+	2:  "unrecognized statement",
+	3:  "expected variable",
+	4:  "expected '=' after variable",
+	5:  "retired: expected relational operator",
+	6:  "expected THEN",
+	7:  "statement cannot be executed",
+	8:  "invalid input",
+	9:  "unknown expression operator",
+	10: "division by zero",
+	11: "unknown term operator",
+	12: "unknown factor",
+	13: "variable not found",
+	14: "value of the wrong type",
+	15: "RETURN without GOSUB or CALL",
+	16: "expected procedure name",
+	17: "expected '{'",
+	18: "expected '}'",
+	19: "expected procedure name after CALL",
+	20: "procedure not found",
+	21: "expected ')'",
+	22: "expected expression",
+	23: "invalid numeric literal",
+	24: "comments are not enabled",
+	25: "expected ';' or ',' between PRINT items",
+	26: "expected ';' or ',' after INPUT prompt",
+	27: "END IF without a matching IF",
+	28: "invalid line number",
+	29: "'$' is only allowed at the end of a variable name",
+	30: "type mismatch",
+	31: "IF block without END IF",
+	32: "ELSE or ELSEIF ... THEN must end the line in a block IF",
+	33: "block terminator without a matching opening statement",
+	34: "loop is never closed (missing WEND, LOOP or NEXT)",
+	35: "NEXT variable does not match FOR",
+	36: "EXIT outside a loop of that kind",
+	37: "expected WHILE or UNTIL",
+	38: "expected TO",
+	39: "expected FOR or DO after EXIT",
+	40: "procedure defined more than once",
+	41: "parameter listed twice",
+	42: "wrong number of arguments",
+	43: "RETURN with a value outside a FUNC",
+	44: "RETURN in a FUNC needs a value",
+	45: "a PROC does not return a value",
+	46: "wrong number of subscripts",
+	47: "expected OPTION BASE 0 or OPTION BASE 1",
+	48: "array used before DIM",
+	49: "subscript out of range",
+	50: "array dimensioned twice",
+	51: "expected label or line number",
+	52: "undefined label or line number",
+	53: "label or line number defined twice",
+	54: "jump into a block",
+	55: "expected GOTO or GOSUB after ON",
+	56: "stack overflow",
+	57: "name of a built-in function",
+	58: "illegal function call",
+	59: "unterminated string literal",
+	60: "unexpected token after statement",
+	61: "array too large",
+	62: "overflow",
+}
+
+// ErrorMessage returns the catalogue message for an error code
+func ErrorMessage(code int) string {
+	if message, ok := ErrorMessages[code]; ok {
+		return message
+	}
+	return fmt.Sprintf("error code %d", code)
+}
+
+// NewDiagnostic creates an error diagnostic pointing at a token, with the catalogue message for its code
+func NewDiagnostic(code int, token *Token) Diagnostic {
+	return Diagnostic{
+		Severity: SEVERITY_ERROR,
+		Code:     code,
+		Message:  ErrorMessage(code),
+		Line:     token.Line,
+		Column:   token.Pos + 1,
+		Span:     len(token.Content),
+	}
+}
+
+// String formats the diagnostic as "file:line:column: message", leaving out the parts that are unknown
+func (d Diagnostic) String() string {
+	var parts []string
+	if d.File != "" {
+		parts = append(parts, d.File)
+	}
+	if d.Line > 0 {
+		parts = append(parts, fmt.Sprint(d.Line))
+		if d.Column > 0 {
+			parts = append(parts, fmt.Sprint(d.Column))
+		}
+	}
+	position := strings.Join(parts, ":")
+	if position == "" {
+		return d.Message
+	}
+	if d.Severity == SEVERITY_WARNING {
+		return fmt.Sprintf("%s: warning: %s", position, d.Message)
+	}
+	return fmt.Sprintf("%s: %s", position, d.Message)
+}
+
+// Diagnostics collects every diagnostic reported while parsing and running a program
+type Diagnostics struct {
+	File string       // File name given to diagnostics that do not name one
+	List []Diagnostic // Diagnostics in the order they were reported
+}
+
+// NewDiagnostics creates an empty collector for the given source file
+func NewDiagnostics(file string) *Diagnostics {
+	return &Diagnostics{File: file}
+}
+
+// Report adds a diagnostic, filling in the file name and catalogue message when missing
+func (d *Diagnostics) Report(diagnostic Diagnostic) {
+	if diagnostic.File == "" {
+		diagnostic.File = d.File
+	}
+	if diagnostic.Message == "" {
+		diagnostic.Message = ErrorMessage(diagnostic.Code)
+	}
+	d.List = append(d.List, diagnostic)
+}
+
+// GetCode returns the code of the first error, or 0 if there is none
+func (d *Diagnostics) GetCode() int {
+	for _, diagnostic := range d.List {
+		if diagnostic.Severity == SEVERITY_ERROR {
+			return diagnostic.Code
+		}
+	}
+	return 0
+}
+
+// Sort orders the diagnostics by their position in the file, keeping the order of those at the same position
+func (d *Diagnostics) Sort() {
+	sort.SliceStable(d.List, func(a, b int) bool {
+		if d.List[a].Line != d.List[b].Line {
+			return d.List[a].Line < d.List[b].Line
+		}
+		return d.List[a].Column < d.List[b].Column
+	})
+}
+
+// Errors returns the diagnostics with error severity
+func (d *Diagnostics) Errors() []Diagnostic {
+	var errors []Diagnostic
+	for _, diagnostic := range d.List {
+		if diagnostic.Severity == SEVERITY_ERROR {
+			errors = append(errors, diagnostic)
+		}
+	}
+	return errors
+}
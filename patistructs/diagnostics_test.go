@@ -0,0 +1,59 @@
+package patistructs
+
+import "testing"
+
+func TestErrorMessagesAreDistinct(t *testing.T) {
+	codes := make(map[string]int)
+	for code, message := range ErrorMessages {
+		if other, exists := codes[message]; exists {
+			t.Errorf("codes %d and %d share the message %q", other, code, message)
+		}
+		codes[message] = code
+	}
+}
+
+func TestErrorMessagesHaveNoGaps(t *testing.T) {
+	for code := 1; code <= len(ErrorMessages); code++ {
+		if _, exists := ErrorMessages[code]; !exists {
+			t.Errorf("code %d has no message", code)
+		}
+	}
+}
+
+func TestDiagnosticString(t *testing.T) {
+	tests := []struct {
+		name       string
+		diagnostic Diagnostic
+		want       string
+	}{
+		{"full position", Diagnostic{Message: "expected THEN", File: "a.bas", Line: 3, Column: 9}, "a.bas:3:9: expected THEN"},
+		{"line only", Diagnostic{Message: "division by zero", File: "a.bas", Line: 3}, "a.bas:3: division by zero"},
+		{"no file", Diagnostic{Message: "expected THEN", Line: 3, Column: 9}, "3:9: expected THEN"},
+		{"no position", Diagnostic{Message: "stack overflow"}, "stack overflow"},
+		{"warning", Diagnostic{Severity: SEVERITY_WARNING, Message: "unused", File: "a.bas", Line: 2}, "a.bas:2: warning: unused"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.diagnostic.String(); got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestDiagnosticsGetCode(t *testing.T) {
+	diagnostics := NewDiagnostics("a.bas")
+	diagnostics.Report(Diagnostic{Severity: SEVERITY_WARNING, Message: "unused"})
+	if code := diagnostics.GetCode(); code != 0 {
+		t.Errorf("a warning gave code %d, want 0", code)
+	}
+	diagnostics.Report(Diagnostic{Code: 13, Line: 4})
+	diagnostics.Report(Diagnostic{Code: 10, Line: 2})
+	if code := diagnostics.GetCode(); code != 13 {
+		t.Errorf("got code %d, want the first error's 13", code)
+	}
+	if last := diagnostics.List[len(diagnostics.List)-1]; last.File != "a.bas" || last.Message != "division by zero" {
+		t.Errorf("got file %q and message %q, want them filled in", last.File, last.Message)
+	}
+}
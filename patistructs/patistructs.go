@@ -1,16 +1,40 @@
 // /home/megalith/pati/patistructs/patistructs.go
 package patistructs
 
-// ErrorHandler interface to handle errors
-type ErrorHandler interface {
-	SetCode(errorCode int, line int)
-	GetCode() int
-}
+import "strings"
 
 // LanguageOptions struct for compiler options
 type LanguageOptions struct {
 	CommentsEnabled bool
-	GosubLimit      int
+	GosubLimit      int  // Maximum depth of nested CALL, GOSUB and function calls (0 for DefaultGosubLimit, at most MaxGosubLimit)
+	CaseInsensitive bool // Treat "print" and "PRINT", "total" and "TOTAL" as the same word
+	FloatDivision   bool // Make '/' produce a float; when false it truncates like '\\'
+	ArrayBase       int  // Lowest subscript of every array dimension, 0 or 1, until OPTION BASE changes it
+	StringEscapes   bool // Decode \n, \t, \" and \\ in string literals, besides the classic doubled quote
+}
+
+// DefaultGosubLimit is the call depth at which a runaway recursion stops with a stack overflow
+const DefaultGosubLimit = 1000
+
+// MaxGosubLimit is the highest GosubLimit allowed; deeper calls could exhaust the Go stack and crash the interpreter
+const MaxGosubLimit = 100000
+
+// NewLanguageOptions creates LanguageOptions with classic BASIC defaults
+func NewLanguageOptions() *LanguageOptions {
+	return &LanguageOptions{
+		CommentsEnabled: true,
+		CaseInsensitive: true,
+		FloatDivision:   true,
+		GosubLimit:      DefaultGosubLimit,
+	}
+}
+
+// NormalizeName returns the spelling used internally to compare keywords and identifiers
+func (o *LanguageOptions) NormalizeName(name string) string {
+	if o.CaseInsensitive {
+		return strings.ToUpper(name)
+	}
+	return name
 }
 
 // TokenClass enumerates the types of tokens
@@ -26,6 +50,22 @@ const (
 	TOKEN_LET
 	TOKEN_IF
 	TOKEN_THEN
+	TOKEN_ELSE
+	TOKEN_ELSEIF
+	TOKEN_WHILE
+	TOKEN_WEND
+	TOKEN_DO
+	TOKEN_LOOP
+	TOKEN_UNTIL
+	TOKEN_FOR
+	TOKEN_TO
+	TOKEN_STEP
+	TOKEN_NEXT
+	TOKEN_EXIT
+	TOKEN_DIM
+	TOKEN_GOTO
+	TOKEN_GOSUB
+	TOKEN_ON
 	TOKEN_RETURN
 	TOKEN_END
 	TOKEN_PRINT
@@ -38,6 +78,11 @@ const (
 	TOKEN_NUMBER
 	TOKEN_SEMICOLON
 	TOKEN_DIVIDE
+	TOKEN_INTEGER_DIVIDE
+	TOKEN_MOD
+	TOKEN_AND
+	TOKEN_OR
+	TOKEN_NOT
 	TOKEN_LEFT_PARENTHESIS
 	TOKEN_RIGHT_PARENTHESIS
 	TOKEN_LEFT_BRACE
@@ -49,6 +94,7 @@ const (
 	TOKEN_GREATERTHAN
 	TOKEN_GREATEROREQUAL
 	TOKEN_COMMA
+	TOKEN_COLON
 	TOKEN_ILLEGAL
 )
 
@@ -57,7 +103,8 @@ type Token struct {
 	Class   TokenClass
 	Line    int
 	Pos     int
-	Content string
+	Content string // Source text of the token, including the quotes of a string literal
+	Value   string // Text of a string literal without its quotes and with escapes decoded
 }
 
 // NewToken creates a new Token without initialization
@@ -99,25 +146,57 @@ const (
 	FACTOR_VARIABLE
 	FACTOR_VALUE
 	FACTOR_EXPRESSION
+	FACTOR_CALL
+	FACTOR_ARRAY
 )
 
 // FactorNode struct
 type FactorNode struct {
 	Class      FactorClass
+	Type       ValueClass // Static type, checked by the parser
 	Sign       int
-	Variable   int
-	Value      int
+	Variable   int   // Slot in the program's SymbolTable
+	Value      Value // Literal value
 	Expression *ExpressionNode
+	CallName   string            // Name of the FUNC or built-in function to call
+	CallSource string            // CallName as written in the source, for messages
+	Arguments  []*ArgumentNode   // Arguments passed to the function
+	Indices    []*ExpressionNode // Subscripts of an array element
 }
 
-// ExpressionNode struct
+// ExpressionNode struct: one or more conjunctions joined by OR
 type ExpressionNode struct {
+	Type  ValueClass // Static type, checked by the parser
+	First *ConjunctionNode
+}
+
+// ConjunctionNode struct: one or more relations joined by AND
+type ConjunctionNode struct {
+	Type  ValueClass // Static type, checked by the parser
+	First *RelationNode
+	Next  *ConjunctionNode // Next operand of OR, evaluated only while the result is false
+}
+
+// RelationNode struct: a sum, optionally compared with a second sum and optionally negated by NOT
+type RelationNode struct {
+	Type  ValueClass // Static type, checked by the parser
+	Not   bool       // Negate the truth value of the relation
+	Left  *SumNode
+	Op    RelationalOperator // Only meaningful when Right is set
+	Right *SumNode           // nil when there is no comparison
+	Next  *RelationNode      // Next operand of AND, evaluated only while the result is true
+}
+
+// SumNode struct: a term followed by any number of '+' or '-' terms
+type SumNode struct {
+	Type ValueClass // Static type, checked by the parser
 	Term *TermNode
 	Next *RightHandTerm
 }
 
 // TermNode struct
 type TermNode struct {
+	Type   ValueClass // Static type, checked by the parser
 	Factor *FactorNode
 	Next   *RightHandFactor
 }
@@ -143,6 +222,8 @@ const (
 	TERM_OPERATOR_NONE TermOperator = iota
 	TERM_OPERATOR_MULTIPLY
 	TERM_OPERATOR_DIVIDE
+	TERM_OPERATOR_INTEGER_DIVIDE
+	TERM_OPERATOR_MOD
 )
 
 // ExpressionOperator enumerates the types of expression operators
@@ -166,20 +247,79 @@ const (
 	STATEMENT_PRINT
 	STATEMENT_INPUT
 	STATEMENT_CALL
+	STATEMENT_WHILE
+	STATEMENT_DO
+	STATEMENT_FOR
+	STATEMENT_EXIT
+	STATEMENT_DIM
+	STATEMENT_OPTION
+	STATEMENT_GOTO
+	STATEMENT_GOSUB
+	STATEMENT_RANDOMIZE
 )
 
 // LetStatementNode struct
 type LetStatementNode struct {
-	Variable   int
+	Variable   int               // Slot in the program's SymbolTable
+	Indices    []*ExpressionNode // Subscripts when assigning to an array element (nil for a plain variable)
 	Expression *ExpressionNode
 }
 
+// ArrayDeclarationNode struct for one array of a DIM statement
+type ArrayDeclarationNode struct {
+	Array  int               // Slot of the array in the program's SymbolTable
+	Base   int               // Lowest subscript of every dimension, from OPTION BASE
+	Bounds []*ExpressionNode // Highest subscript of each dimension
+}
+
+// DimStatementNode struct for DIM A(10), M(3, 4), ...
+type DimStatementNode struct {
+	Arrays []*ArrayDeclarationNode
+}
+
 // IfStatementNode struct
 type IfStatementNode struct {
-	Left      *ExpressionNode
-	Op        RelationalOperator
-	Right     *ExpressionNode
-	Statement *StatementNode
+	Condition *ExpressionNode  // Numeric expression, true when not zero
+	Block     bool             // Multi-line form terminated by END IF
+	Statement *StatementNode   // Single-line form: statement after THEN
+	Else      *StatementNode   // Single-line form: optional statement after ELSE
+	Then      *ProgramLineNode // Block form: lines run when the condition holds
+	ElseIf    *IfStatementNode // Block form: next ELSEIF branch, tried when the condition fails
+	ElseLines *ProgramLineNode // Block form: lines after ELSE
+}
+
+// WhileStatementNode struct for WHILE ... WEND
+type WhileStatementNode struct {
+	Condition *ExpressionNode
+	Body      *ProgramLineNode
+}
+
+// LoopConditionNode struct for the WHILE or UNTIL test at either end of a DO loop
+type LoopConditionNode struct {
+	Until     bool // Keep looping until the condition holds, rather than while it holds
+	Condition *ExpressionNode
+}
+
+// DoStatementNode struct for DO [WHILE|UNTIL cond] ... LOOP [WHILE|UNTIL cond]
+type DoStatementNode struct {
+	Pre  *LoopConditionNode // Tested before each pass (nil if none)
+	Post *LoopConditionNode // Tested after each pass (nil if none)
+	Body *ProgramLineNode
+}
+
+// ForStatementNode struct for FOR var = start TO limit [STEP step] ... NEXT [var]
+type ForStatementNode struct {
+	Variable int             // Slot of the loop variable in the program's SymbolTable
+	Start    *ExpressionNode // Initial value
+	Limit    *ExpressionNode // Final value, evaluated once before the first pass
+	Step     *ExpressionNode // Increment, evaluated once (nil means 1)
+	Body     *ProgramLineNode
+}
+
+// JumpStatementNode struct for GOTO, GOSUB and ON <selector> GOTO/GOSUB
+type JumpStatementNode struct {
+	Selector *ExpressionNode    // ON: picks the target, counting from 1 (nil for a plain GOTO or GOSUB)
+	Targets  []*ProgramLineNode // Lines jumped to, resolved when the program is parsed
 }
 
 // PrintStatementNode struct
@@ -189,46 +329,130 @@ type PrintStatementNode struct {
 
 // InputStatementNode struct
 type InputStatementNode struct {
-	First *VariableListNode
+	Prompt       string            // Optional prompt printed before reading
+	QuestionMark bool              // Whether "? " is printed after the prompt
+	First        *VariableListNode // Variables receiving the comma-separated answers
 }
 
 // ArgumentNode struct for procedure arguments
 type ArgumentNode struct {
-	Name  string // Name of the argument
-	Type  string // Type of the argument (e.g., "string", "int")
-	Value interface{} // Value of the argument
+	Expression *ExpressionNode // Evaluated at the call site and passed by value
 }
 
 // StatementNode struct
 type StatementNode struct {
-	Class     StatementClass
-	LetNode   *LetStatementNode
-	IfNode    *IfStatementNode
-	PrintNode *PrintStatementNode
-	InputNode *InputStatementNode
-	CallName  string          // Name of the procedure to CALL
-	Arguments []*ArgumentNode // Arguments passed to the procedure
+	Class       StatementClass
+	LetNode     *LetStatementNode
+	IfNode      *IfStatementNode
+	PrintNode   *PrintStatementNode
+	InputNode   *InputStatementNode
+	WhileNode   *WhileStatementNode
+	DoNode      *DoStatementNode
+	ForNode     *ForStatementNode
+	DimNode     *DimStatementNode
+	JumpNode    *JumpStatementNode
+	ExitClass   StatementClass  // Loop left by EXIT: STATEMENT_FOR or STATEMENT_DO
+	CallName    string          // Name of the procedure to CALL
+	CallSource  string          // CallName as written in the source, for messages
+	Arguments   []*ArgumentNode // Arguments passed to the procedure
+	ReturnValue *ExpressionNode // Value of a RETURN inside a FUNC (nil elsewhere)
+	Seed        *ExpressionNode // Seed of RANDOMIZE (nil to seed from the clock)
+	Next        *StatementNode  // Statement after ':' in the same branch of a single-line IF
+}
+
+// ProcedureNode struct for a PROC or FUNC definition
+type ProcedureNode struct {
+	Name       string           // Normalized name
+	Function   bool             // Declared with FUNC, so calls yield the value of RETURN
+	Type       ValueClass       // Type of the returned value, from the name like a variable
+	Parameters []int            // Slots of the formal parameters, local to each call
+	Body       *ProgramLineNode // Lines of the procedure (nil if empty)
 }
 
 // ProgramNode struct
 type ProgramNode struct {
-	Procedures map[string]*ProgramLineNode // Map of named procedures
-	Main       *ProgramLineNode            // Entry point of the main program
+	Procedures map[string]*ProcedureNode // Map of named procedures
+	Main       *ProgramLineNode          // Entry point of the main program
+	Symbols    *SymbolTable              // Variable names resolved to slots
+}
+
+// SymbolTable maps variable names to the slots the interpreter stores their values in
+type SymbolTable struct {
+	Names []string       // Source spelling of each slot, indexed by slot
+	slots map[string]int // Normalized name to slot
+}
+
+// NewSymbolTable creates an empty SymbolTable
+func NewSymbolTable() *SymbolTable {
+	return &SymbolTable{
+		slots: make(map[string]int),
+	}
+}
+
+// Resolve returns the slot for a normalized name, allocating one the first time the name is seen
+func (t *SymbolTable) Resolve(normalized string, spelling string) int {
+	if slot, exists := t.slots[normalized]; exists {
+		return slot
+	}
+	slot := len(t.Names)
+	t.slots[normalized] = slot
+	t.Names = append(t.Names, spelling)
+	return slot
+}
+
+// Lookup returns the slot for a normalized name, if it has one
+func (t *SymbolTable) Lookup(normalized string) (int, bool) {
+	slot, exists := t.slots[normalized]
+	return slot, exists
+}
+
+// Name returns the source spelling of a slot
+func (t *SymbolTable) Name(slot int) string {
+	if slot < 0 || slot >= len(t.Names) {
+		return "?"
+	}
+	return t.Names[slot]
 }
 
 // ProgramLineNode struct
 type ProgramLineNode struct {
-	ProcedureName string            // Name of the procedure (if applicable)
-	Statement     *StatementNode    // Statement in the line
-	Next          *ProgramLineNode  // Next line in the procedure or main program
+	Line       int              // Source line the statement starts on
+	LineNumber int              // Classic BASIC line number (0 if none)
+	Label      string           // Optional label, written as "Name:" (empty if none)
+	Block      int              // Identifies the chain of lines holding this one, so GOTO can find its target
+	Statement  *StatementNode   // Statement in the line (nil for a bare label or line number)
+	Next       *ProgramLineNode // Next line, or next statement after ':', in the procedure or main program
 }
 
+// OutputClass enumerates the types of PRINT items
+type OutputClass int
+
+const (
+	OUTPUT_NONE OutputClass = iota
+	OUTPUT_STRING
+	OUTPUT_EXPRESSION
+)
+
+// OutputSeparator enumerates the separators that may follow a PRINT item
+type OutputSeparator int
+
+const (
+	OUTPUT_SEPARATOR_NONE      OutputSeparator = iota
+	OUTPUT_SEPARATOR_SEMICOLON                 // Continue printing directly after the item
+	OUTPUT_SEPARATOR_COMMA                     // Continue printing at the next print zone
+)
+
 // OutputNode struct
 type OutputNode struct {
-	Value string // Output value (e.g., variables or strings)
+	Class      OutputClass
+	Value      string          // String literal to print
+	Expression *ExpressionNode // Expression to print
+	Separator  OutputSeparator // Separator following the item
+	Next       *OutputNode     // Next item in the PRINT list
 }
 
 // VariableListNode struct
 type VariableListNode struct {
-	Variables []int // List of variables
+	Variables []int               // List of variable slots in the program's SymbolTable
+	Indices   [][]*ExpressionNode // Subscripts of each variable that is an array element (nil for plain variables)
 }
@@ -0,0 +1,104 @@
+package patistructs
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ValueClass enumerates the types of runtime values
+type ValueClass int
+
+const (
+	VALUE_NONE ValueClass = iota // Unassigned
+	VALUE_INT
+	VALUE_FLOAT
+	VALUE_STRING
+)
+
+// Value is a tagged runtime value; only the field matching Class is meaningful
+type Value struct {
+	Class ValueClass
+	Int   int
+	Float float64
+	Text  string
+}
+
+// IntValue creates an integer Value
+func IntValue(n int) Value {
+	return Value{Class: VALUE_INT, Int: n}
+}
+
+// FloatValue creates a floating-point Value
+func FloatValue(f float64) Value {
+	return Value{Class: VALUE_FLOAT, Float: f}
+}
+
+// StringValue creates a string Value
+func StringValue(s string) Value {
+	return Value{Class: VALUE_STRING, Text: s}
+}
+
+// BoolValue creates the truth value of a comparison or logical operator: -1 for true, 0 for false
+func BoolValue(b bool) Value {
+	if b {
+		return IntValue(-1)
+	}
+	return IntValue(0)
+}
+
+// IsNumeric reports whether the value is an integer or a float
+func (v Value) IsNumeric() bool {
+	return v.Class == VALUE_INT || v.Class == VALUE_FLOAT
+}
+
+// AsFloat returns a numeric value as a float
+func (v Value) AsFloat() float64 {
+	if v.Class == VALUE_FLOAT {
+		return v.Float
+	}
+	return float64(v.Int)
+}
+
+// AsInt returns a numeric value as an integer, truncating floats toward zero; ok is false if the float is outside the range of int
+func (v Value) AsInt() (n int, ok bool) {
+	if v.Class == VALUE_FLOAT {
+		// Go leaves the conversion of an out of range float undefined, so check first; -MinInt is 2^63 as a float
+		f := math.Trunc(v.Float)
+		if !(f >= math.MinInt && f < -math.MinInt) {
+			return 0, false
+		}
+		return int(f), true
+	}
+	return v.Int, true
+}
+
+// IsTrue reports whether a numeric value counts as true in a condition, which is any value but zero
+func (v Value) IsTrue() bool {
+	return v.AsFloat() != 0
+}
+
+// String formats the value the way PRINT shows it
+func (v Value) String() string {
+	switch v.Class {
+	case VALUE_INT:
+		return strconv.Itoa(v.Int)
+	case VALUE_FLOAT:
+		// Use plain notation unless the number is very large or very small
+		if magnitude := math.Abs(v.Float); magnitude != 0 && (magnitude < 1e-6 || magnitude >= 1e21) {
+			return strconv.FormatFloat(v.Float, 'g', -1, 64)
+		}
+		return strconv.FormatFloat(v.Float, 'f', -1, 64)
+	case VALUE_STRING:
+		return v.Text
+	}
+	return ""
+}
+
+// VariableClass returns the static type of a variable from its name: "A$" is a string, "A" is numeric
+func VariableClass(name string) ValueClass {
+	if strings.HasSuffix(name, "$") {
+		return VALUE_STRING
+	}
+	return VALUE_INT
+}
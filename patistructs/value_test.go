@@ -0,0 +1,32 @@
+package patistructs
+
+import (
+	"math"
+	"testing"
+)
+
+func TestAsInt(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  Value
+		want   int
+		wantOk bool
+	}{
+		{"integer", IntValue(-7), -7, true},
+		{"float truncated toward zero", FloatValue(-2.9), -2, true},
+		{"lowest int", FloatValue(-9223372036854775808), math.MinInt64, true},
+		{"just below the lowest int", FloatValue(-9223372036854777856), 0, false},
+		{"2^63", FloatValue(9223372036854775808), 0, false},
+		{"infinity", FloatValue(math.Inf(1)), 0, false},
+		{"not a number", FloatValue(math.NaN()), 0, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, ok := test.value.AsInt()
+			if got != test.want || ok != test.wantOk {
+				t.Errorf("got %d, %v, want %d, %v", got, ok, test.want, test.wantOk)
+			}
+		})
+	}
+}
@@ -1,19 +1,62 @@
 package tokenizer
 
 import (
+	"pati/patistructs"
 	"strings"
 	"unicode"
-	"pati/patistructs"
 )
 
+// keywords maps the normalized spelling of each keyword to its token class
+var keywords = map[string]patistructs.TokenClass{
+	"LET":       patistructs.TOKEN_LET,
+	"IF":        patistructs.TOKEN_IF,
+	"THEN":      patistructs.TOKEN_THEN,
+	"ELSE":      patistructs.TOKEN_ELSE,
+	"ELSEIF":    patistructs.TOKEN_ELSEIF,
+	"RETURN":    patistructs.TOKEN_RETURN,
+	"END":       patistructs.TOKEN_END,
+	"PRINT":     patistructs.TOKEN_PRINT,
+	"INPUT":     patistructs.TOKEN_INPUT,
+	"REM":       patistructs.TOKEN_REM,
+	"MOD":       patistructs.TOKEN_MOD,
+	"AND":       patistructs.TOKEN_AND,
+	"OR":        patistructs.TOKEN_OR,
+	"NOT":       patistructs.TOKEN_NOT,
+	"WHILE":     patistructs.TOKEN_WHILE,
+	"WEND":      patistructs.TOKEN_WEND,
+	"DO":        patistructs.TOKEN_DO,
+	"LOOP":      patistructs.TOKEN_LOOP,
+	"UNTIL":     patistructs.TOKEN_UNTIL,
+	"FOR":       patistructs.TOKEN_FOR,
+	"TO":        patistructs.TOKEN_TO,
+	"STEP":      patistructs.TOKEN_STEP,
+	"NEXT":      patistructs.TOKEN_NEXT,
+	"EXIT":      patistructs.TOKEN_EXIT,
+	"DIM":       patistructs.TOKEN_DIM,
+	"GOTO":      patistructs.TOKEN_GOTO,
+	"GOSUB":     patistructs.TOKEN_GOSUB,
+	"ON":        patistructs.TOKEN_ON,
+	"PROC":      patistructs.TOKEN_WORD,
+	"FUNC":      patistructs.TOKEN_WORD,
+	"OPTION":    patistructs.TOKEN_WORD,
+	"CALL":      patistructs.TOKEN_WORD,
+	"RANDOMIZE": patistructs.TOKEN_WORD,
+}
+
 // Tokenize function takes the content of a BASIC program and returns a list of tokens
 func Tokenize(content string) []*patistructs.Token {
+	return TokenizeWithOptions(content, patistructs.NewLanguageOptions())
+}
+
+// TokenizeWithOptions tokenizes a BASIC program using the given language options
+func TokenizeWithOptions(content string, options *patistructs.LanguageOptions) []*patistructs.Token {
 	var tokens []*patistructs.Token
 	lines := strings.Split(content, "\n")
 	lineNumber := 1
 
 	for _, line := range lines {
-		line = strings.TrimSpace(line)
+		// Only trailing space is trimmed so token positions count from the real start of the line
+		line = strings.TrimRightFunc(line, unicode.IsSpace)
 		if len(line) == 0 {
 			lineNumber++
 			continue
@@ -42,9 +85,42 @@ func Tokenize(content string) []*patistructs.Token {
 			case '/':
 				tokens = append(tokens, patistructs.NewTokenWithValues(patistructs.TOKEN_DIVIDE, lineNumber, pos, string(ch)))
 				pos++
+			case '\\':
+				tokens = append(tokens, patistructs.NewTokenWithValues(patistructs.TOKEN_INTEGER_DIVIDE, lineNumber, pos, string(ch)))
+				pos++
 			case '=':
 				tokens = append(tokens, patistructs.NewTokenWithValues(patistructs.TOKEN_EQUAL, lineNumber, pos, string(ch)))
 				pos++
+			case '<':
+				// Look ahead for "<=" and "<>"
+				if pos+1 < len(line) && line[pos+1] == '=' {
+					tokens = append(tokens, patistructs.NewTokenWithValues(patistructs.TOKEN_LESSOREQUAL, lineNumber, pos, line[pos:pos+2]))
+					pos += 2
+				} else if pos+1 < len(line) && line[pos+1] == '>' {
+					tokens = append(tokens, patistructs.NewTokenWithValues(patistructs.TOKEN_UNEQUAL, lineNumber, pos, line[pos:pos+2]))
+					pos += 2
+				} else {
+					tokens = append(tokens, patistructs.NewTokenWithValues(patistructs.TOKEN_LESSTHAN, lineNumber, pos, string(ch)))
+					pos++
+				}
+			case '>':
+				// Look ahead for ">="
+				if pos+1 < len(line) && line[pos+1] == '=' {
+					tokens = append(tokens, patistructs.NewTokenWithValues(patistructs.TOKEN_GREATEROREQUAL, lineNumber, pos, line[pos:pos+2]))
+					pos += 2
+				} else {
+					tokens = append(tokens, patistructs.NewTokenWithValues(patistructs.TOKEN_GREATERTHAN, lineNumber, pos, string(ch)))
+					pos++
+				}
+			case '!':
+				// "!=" is accepted as an alternative spelling of "<>"
+				if pos+1 < len(line) && line[pos+1] == '=' {
+					tokens = append(tokens, patistructs.NewTokenWithValues(patistructs.TOKEN_UNEQUAL, lineNumber, pos, line[pos:pos+2]))
+					pos += 2
+				} else {
+					tokens = append(tokens, patistructs.NewTokenWithValues(patistructs.TOKEN_ILLEGAL, lineNumber, pos, string(ch)))
+					pos++
+				}
 			case '(':
 				tokens = append(tokens, patistructs.NewTokenWithValues(patistructs.TOKEN_LEFT_PARENTHESIS, lineNumber, pos, string(ch)))
 				pos++
@@ -57,20 +133,31 @@ func Tokenize(content string) []*patistructs.Token {
 			case '}':
 				tokens = append(tokens, patistructs.NewTokenWithValues(patistructs.TOKEN_RIGHT_BRACE, lineNumber, pos, string(ch)))
 				pos++
+			case ';':
+				tokens = append(tokens, patistructs.NewTokenWithValues(patistructs.TOKEN_SEMICOLON, lineNumber, pos, string(ch)))
+				pos++
+			case ':':
+				tokens = append(tokens, patistructs.NewTokenWithValues(patistructs.TOKEN_COLON, lineNumber, pos, string(ch)))
+				pos++
 			case ',':
 				tokens = append(tokens, patistructs.NewTokenWithValues(patistructs.TOKEN_COMMA, lineNumber, pos, string(ch)))
 				pos++
+			case '\'':
+				// The apostrophe is shorthand for REM and comments out the rest of the line
+				tokens = append(tokens, patistructs.NewTokenWithValues(patistructs.TOKEN_REM, lineNumber, pos, line[pos:]))
+				pos = len(line)
 			case '"':
-				// Parse string literal
+				// Parse string literal; one left open is illegal, and the parser reports it
 				startPos := pos
-				pos++
-				for pos < len(line) && line[pos] != '"' {
-					pos++
+				value, end, terminated := scanString(line, pos, options.StringEscapes)
+				pos = end
+				class := patistructs.TOKEN_STRING
+				if !terminated {
+					class = patistructs.TOKEN_ILLEGAL
 				}
-				if pos < len(line) {
-					pos++ // Include the closing quote
-				}
-				tokens = append(tokens, patistructs.NewTokenWithValues(patistructs.TOKEN_STRING, lineNumber, startPos, line[startPos:pos]))
+				token := patistructs.NewTokenWithValues(class, lineNumber, startPos, line[startPos:pos])
+				token.Value = value
+				tokens = append(tokens, token)
 			default:
 				if unicode.IsLetter(rune(ch)) {
 					// Parse identifier or keyword
@@ -78,31 +165,28 @@ func Tokenize(content string) []*patistructs.Token {
 					for pos < len(line) && (unicode.IsLetter(rune(line[pos])) || unicode.IsDigit(rune(line[pos])) || line[pos] == '$') {
 						pos++
 					}
+					// Keywords are matched on the normalized spelling; Content keeps the original
 					word := line[startPos:pos]
-					switch word {
-					case "LET":
-						tokens = append(tokens, patistructs.NewTokenWithValues(patistructs.TOKEN_LET, lineNumber, startPos, word))
-					case "IF":
-						tokens = append(tokens, patistructs.NewTokenWithValues(patistructs.TOKEN_IF, lineNumber, startPos, word))
-					case "THEN":
-						tokens = append(tokens, patistructs.NewTokenWithValues(patistructs.TOKEN_THEN, lineNumber, startPos, word))
-					case "RETURN":
-						tokens = append(tokens, patistructs.NewTokenWithValues(patistructs.TOKEN_RETURN, lineNumber, startPos, word))
-					case "END":
-						tokens = append(tokens, patistructs.NewTokenWithValues(patistructs.TOKEN_END, lineNumber, startPos, word))
-					case "PRINT":
-						tokens = append(tokens, patistructs.NewTokenWithValues(patistructs.TOKEN_PRINT, lineNumber, startPos, word))
-					case "INPUT":
-						tokens = append(tokens, patistructs.NewTokenWithValues(patistructs.TOKEN_INPUT, lineNumber, startPos, word))
-					case "PROC":
-						tokens = append(tokens, patistructs.NewTokenWithValues(patistructs.TOKEN_WORD, lineNumber, startPos, word))
-					default:
-						tokens = append(tokens, patistructs.NewTokenWithValues(patistructs.TOKEN_VARIABLE, lineNumber, startPos, word))
+					class, isKeyword := keywords[options.NormalizeName(word)]
+					if !isKeyword {
+						class = patistructs.TOKEN_VARIABLE
+					}
+					if class == patistructs.TOKEN_REM {
+						// Keep the whole comment as a single token so the text is not lost
+						word = line[startPos:]
+						pos = len(line)
 					}
-				} else if unicode.IsDigit(rune(ch)) {
-					// Parse number
+					tokens = append(tokens, patistructs.NewTokenWithValues(class, lineNumber, startPos, word))
+				} else if unicode.IsDigit(rune(ch)) || (ch == '.' && pos+1 < len(line) && unicode.IsDigit(rune(line[pos+1]))) {
+					// Parse decimal number, e.g. "42", "3.14", ".5" or "1E6"
 					startPos := pos
-					for pos < len(line) && unicode.IsDigit(rune(line[pos])) {
+					pos = scanDecimal(line, pos)
+					tokens = append(tokens, patistructs.NewTokenWithValues(patistructs.TOKEN_NUMBER, lineNumber, startPos, line[startPos:pos]))
+				} else if ch == '&' && pos+1 < len(line) && strings.ContainsRune("HhBb", rune(line[pos+1])) {
+					// Parse hexadecimal "&HFF" or binary "&B1010" number
+					startPos := pos
+					pos += 2
+					for pos < len(line) && (unicode.IsLetter(rune(line[pos])) || unicode.IsDigit(rune(line[pos]))) {
 						pos++
 					}
 					tokens = append(tokens, patistructs.NewTokenWithValues(patistructs.TOKEN_NUMBER, lineNumber, startPos, line[startPos:pos]))
@@ -113,8 +197,71 @@ func Tokenize(content string) []*patistructs.Token {
 				}
 			}
 		}
+		// Every line holding tokens ends with TOKEN_EOL, so the parser can tell where its statements end
+		tokens = append(tokens, patistructs.NewTokenWithValues(patistructs.TOKEN_EOL, lineNumber, len(line), ""))
 		lineNumber++
 	}
 
 	return tokens
 }
+
+// Helper function to decode the string literal starting at the '"' at pos, returning its text and where it ends
+func scanString(line string, pos int, escapes bool) (string, int, bool) {
+	var value strings.Builder
+	pos++ // Move past the opening quote
+	for pos < len(line) {
+		ch := line[pos]
+		switch {
+		case ch == '"' && pos+1 < len(line) && line[pos+1] == '"':
+			// A doubled quote stands for one quote, as in "Say ""hello"""
+			value.WriteByte('"')
+			pos += 2
+		case ch == '"':
+			return value.String(), pos + 1, true
+		case ch == '\\' && escapes && pos+1 < len(line):
+			// Unknown escapes are kept as written
+			switch line[pos+1] {
+			case 'n':
+				value.WriteByte('\n')
+			case 't':
+				value.WriteByte('\t')
+			case '"', '\\':
+				value.WriteByte(line[pos+1])
+			default:
+				value.WriteString(line[pos : pos+2])
+			}
+			pos += 2
+		default:
+			value.WriteByte(ch)
+			pos++
+		}
+	}
+	return value.String(), pos, false
+}
+
+// Helper function to find the end of a decimal number with optional fraction and exponent
+func scanDecimal(line string, pos int) int {
+	for pos < len(line) && unicode.IsDigit(rune(line[pos])) {
+		pos++
+	}
+	if pos < len(line) && line[pos] == '.' {
+		pos++
+		for pos < len(line) && unicode.IsDigit(rune(line[pos])) {
+			pos++
+		}
+	}
+	// The exponent only counts when digits follow, so "2E" stays a number and a variable
+	if pos < len(line) && (line[pos] == 'E' || line[pos] == 'e') {
+		end := pos + 1
+		if end < len(line) && (line[end] == '+' || line[end] == '-') {
+			end++
+		}
+		if end < len(line) && unicode.IsDigit(rune(line[end])) {
+			pos = end
+			for pos < len(line) && unicode.IsDigit(rune(line[pos])) {
+				pos++
+			}
+		}
+	}
+	return pos
+}
@@ -0,0 +1,146 @@
+package tokenizer
+
+import (
+	"pati/patistructs"
+	"testing"
+)
+
+// expectedToken is the part of a Token the tests compare
+type expectedToken struct {
+	class   patistructs.TokenClass
+	line    int
+	pos     int
+	content string
+}
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   []expectedToken
+	}{
+		{
+			name:   "procedure definition and call",
+			source: "PROC Greet {\nCALL Greet : RETURN\n}",
+			want: []expectedToken{
+				{patistructs.TOKEN_WORD, 1, 0, "PROC"},
+				{patistructs.TOKEN_VARIABLE, 1, 5, "Greet"},
+				{patistructs.TOKEN_LEFT_BRACE, 1, 11, "{"},
+				{patistructs.TOKEN_EOL, 1, 12, ""},
+				{patistructs.TOKEN_WORD, 2, 0, "CALL"},
+				{patistructs.TOKEN_VARIABLE, 2, 5, "Greet"},
+				{patistructs.TOKEN_COLON, 2, 11, ":"},
+				{patistructs.TOKEN_RETURN, 2, 13, "RETURN"},
+				{patistructs.TOKEN_EOL, 2, 19, ""},
+				{patistructs.TOKEN_RIGHT_BRACE, 3, 0, "}"},
+				{patistructs.TOKEN_EOL, 3, 1, ""},
+			},
+		},
+		{
+			name:   "label, string and number",
+			source: "Greet: PRINT \"a\"\"b\"; 1.5E3",
+			want: []expectedToken{
+				{patistructs.TOKEN_VARIABLE, 1, 0, "Greet"},
+				{patistructs.TOKEN_COLON, 1, 5, ":"},
+				{patistructs.TOKEN_PRINT, 1, 7, "PRINT"},
+				{patistructs.TOKEN_STRING, 1, 13, "\"a\"\"b\""},
+				{patistructs.TOKEN_SEMICOLON, 1, 19, ";"},
+				{patistructs.TOKEN_NUMBER, 1, 21, "1.5E3"},
+				{patistructs.TOKEN_EOL, 1, 26, ""},
+			},
+		},
+		{
+			name:   "relational operators",
+			source: "A<=1>=2<>3!=4<5>6=7",
+			want: []expectedToken{
+				{patistructs.TOKEN_VARIABLE, 1, 0, "A"},
+				{patistructs.TOKEN_LESSOREQUAL, 1, 1, "<="},
+				{patistructs.TOKEN_NUMBER, 1, 3, "1"},
+				{patistructs.TOKEN_GREATEROREQUAL, 1, 4, ">="},
+				{patistructs.TOKEN_NUMBER, 1, 6, "2"},
+				{patistructs.TOKEN_UNEQUAL, 1, 7, "<>"},
+				{patistructs.TOKEN_NUMBER, 1, 9, "3"},
+				{patistructs.TOKEN_UNEQUAL, 1, 10, "!="},
+				{patistructs.TOKEN_NUMBER, 1, 12, "4"},
+				{patistructs.TOKEN_LESSTHAN, 1, 13, "<"},
+				{patistructs.TOKEN_NUMBER, 1, 14, "5"},
+				{patistructs.TOKEN_GREATERTHAN, 1, 15, ">"},
+				{patistructs.TOKEN_NUMBER, 1, 16, "6"},
+				{patistructs.TOKEN_EQUAL, 1, 17, "="},
+				{patistructs.TOKEN_NUMBER, 1, 18, "7"},
+				{patistructs.TOKEN_EOL, 1, 19, ""},
+			},
+		},
+		{
+			name:   "'!' on its own is illegal",
+			source: "A ! 1",
+			want: []expectedToken{
+				{patistructs.TOKEN_VARIABLE, 1, 0, "A"},
+				{patistructs.TOKEN_ILLEGAL, 1, 2, "!"},
+				{patistructs.TOKEN_NUMBER, 1, 4, "1"},
+				{patistructs.TOKEN_EOL, 1, 5, ""},
+			},
+		},
+		{
+			name:   "blank lines keep the line count",
+			source: "\n\nEND",
+			want: []expectedToken{
+				{patistructs.TOKEN_END, 3, 0, "END"},
+				{patistructs.TOKEN_EOL, 3, 3, ""},
+			},
+		},
+		{
+			name:   "unterminated string",
+			source: "PRINT \"open",
+			want: []expectedToken{
+				{patistructs.TOKEN_PRINT, 1, 0, "PRINT"},
+				{patistructs.TOKEN_ILLEGAL, 1, 6, "\"open"},
+				{patistructs.TOKEN_EOL, 1, 11, ""},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tokens := Tokenize(test.source)
+			if len(tokens) != len(test.want) {
+				t.Fatalf("got %d tokens, want %d", len(tokens), len(test.want))
+			}
+			for n, token := range tokens {
+				got := expectedToken{token.Class, token.Line, token.Pos, token.Content}
+				if got != test.want[n] {
+					t.Errorf("token %d: got %+v, want %+v", n, got, test.want[n])
+				}
+			}
+		})
+	}
+}
+
+func TestStringLiteralValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		escapes bool
+		want    string
+	}{
+		{"plain", `"hello"`, false, "hello"},
+		{"doubled quote", `"say ""hi"""`, false, `say "hi"`},
+		{"backslash kept without escapes", `"a\tb"`, false, `a\tb`},
+		{"escapes decoded", `"a\tb\n\"\\"`, true, "a\tb\n\"\\"},
+		{"UTF-8 text", `"héllo"`, false, "héllo"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			options := patistructs.NewLanguageOptions()
+			options.StringEscapes = test.escapes
+			tokens := TokenizeWithOptions(test.source, options)
+			if len(tokens) == 0 || tokens[0].Class != patistructs.TOKEN_STRING {
+				t.Fatalf("got %v, want a string token", tokens)
+			}
+			if tokens[0].Value != test.want {
+				t.Errorf("got %q, want %q", tokens[0].Value, test.want)
+			}
+		})
+	}
+}